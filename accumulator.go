@@ -0,0 +1,55 @@
+package sherlock
+
+import (
+	"errors"
+	"sync"
+)
+
+// Accumulator collects errors from a batch of operations without panicking,
+// so that every item can be processed and all of the failures reported at
+// once. The zero value is ready to use, and an Accumulator is safe for
+// concurrent use.
+//
+//	var acc sherlock.Accumulator
+//	for _, item := range items {
+//		acc.Check(process(item))
+//	}
+//	acc.Resolve()
+type Accumulator struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// Check records err if it is non nil, after passing it through the registered
+// classifiers, so that the recorded errors match the sentinels they were
+// recognised as.
+func (a *Accumulator) Check(err error) {
+	if err == nil {
+		return
+	}
+	err = classify(err)
+	a.mu.Lock()
+	a.errs = append(a.errs, err)
+	a.mu.Unlock()
+}
+
+// Errors returns the errors recorded so far.
+func (a *Accumulator) Errors() []error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]error(nil), a.errs...)
+}
+
+// Err returns the recorded errors joined into a single error, or nil if none
+// were recorded.
+func (a *Accumulator) Err() error {
+	return errors.Join(a.Errors()...)
+}
+
+// Resolve throws the recorded errors, joined into a single error, as a
+// sherlock panic. It does nothing if no errors were recorded.
+func (a *Accumulator) Resolve() {
+	if err := a.Err(); err != nil {
+		Throw(err)
+	}
+}
@@ -0,0 +1,27 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestAccumulatorClassifies(t *testing.T) {
+	errRaw := errors.New("accumulator test: raw")
+	errSentinel := errors.New("accumulator test: sentinel")
+	sherlock.PushOverrides(sherlock.Overrides{Classifiers: []sherlock.Classifier{func(err error) error {
+		if errors.Is(err, errRaw) {
+			return errSentinel
+		}
+		return nil
+	}}})
+	defer sherlock.Pop()
+	var acc sherlock.Accumulator
+	acc.Check(errRaw)
+	acc.Check(nil)
+	errs := acc.Errors()
+	if len(errs) != 1 || !errors.Is(errs[0], errSentinel) || !errors.Is(errs[0], errRaw) {
+		t.Errorf("recorded %v, want the raw error classified as the sentinel", errs)
+	}
+}
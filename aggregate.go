@@ -0,0 +1,151 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// wireEvent is an Event as streamed from a worker process to an Aggregator.
+type wireEvent struct {
+	PID      int       `json:"pid"`
+	Time     time.Time `json:"time"`
+	Package  string    `json:"package,omitempty"`
+	Err      string    `json:"err"`
+	Action   Action    `json:"action"`
+	Code     string    `json:"code,omitempty"`
+	Severity Severity  `json:"severity"`
+}
+
+// Forward streams every event of this process to the Aggregator listening at
+// addr on the given network, normally "unix", for programs that fork worker
+// processes. Events are redacted before they are sent. The returned function
+// stops forwarding and closes the connection.
+func Forward(network, addr string) (stop func(), err error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	ch := Subscribe(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		enc := json.NewEncoder(conn)
+		pid := os.Getpid()
+		for e := range ch {
+			w := wireEvent{
+				PID:      pid,
+				Time:     e.Time,
+				Package:  e.Package,
+				Err:      redact(fmt.Sprint(e.Err)),
+				Action:   e.Action,
+				Code:     e.Code,
+				Severity: e.Severity,
+			}
+			if enc.Encode(w) != nil {
+				return
+			}
+		}
+	}()
+	return func() {
+		Unsubscribe(ch)
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		<-done
+		conn.Close()
+	}, nil
+}
+
+// Aggregator collects the events forwarded by worker processes, keeping
+// counters for each process and reporting their unexpected panics through this
+// process's diagnostics. Repeated reports of the same error are deduplicated
+// by Dedup, which defaults to one report per error per minute.
+type Aggregator struct {
+	Dedup *Throttle
+
+	mu     sync.Mutex
+	counts map[int]*Counters
+	once   sync.Once
+}
+
+// Serve accepts worker connections on ln until it is closed.
+func (a *Aggregator) Serve(ln net.Listener) error {
+	a.once.Do(func() {
+		if a.Dedup == nil {
+			a.Dedup = &Throttle{Every: time.Minute}
+		}
+	})
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go a.serve(conn)
+	}
+}
+
+func (a *Aggregator) serve(conn net.Conn) {
+	defer conn.Close()
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		var e wireEvent
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		a.observe(e)
+	}
+}
+
+func (a *Aggregator) observe(e wireEvent) {
+	a.mu.Lock()
+	if a.counts == nil {
+		a.counts = make(map[int]*Counters)
+	}
+	c := a.counts[e.PID]
+	if c == nil {
+		c = new(Counters)
+		a.counts[e.PID] = c
+	}
+	switch e.Action {
+	case ActionCaught:
+		c.Caught++
+	case ActionUnexpected:
+		c.Unexpected++
+	}
+	a.mu.Unlock()
+	if e.Action == ActionUnexpected && a.Dedup.Allow(e.Code+"\x00"+e.Err) {
+		diagnoseBug(Entry{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("worker %v: %v", e.PID, e.Err),
+			Package:  e.Package,
+		})
+	}
+}
+
+// Stats returns the counters of each worker process, keyed by process ID.
+// Dropped and NearMisses are always zero, as sampling and fuzzy matching happen
+// in the workers.
+func (a *Aggregator) Stats() map[int]Counters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	m := make(map[int]Counters, len(a.counts))
+	for pid, c := range a.counts {
+		m[pid] = *c
+	}
+	return m
+}
+
+// Total returns the counters of every worker process added together.
+func (a *Aggregator) Total() Counters {
+	var t Counters
+	for _, c := range a.Stats() {
+		t.Caught += c.Caught
+		t.Unexpected += c.Unexpected
+	}
+	return t
+}
@@ -0,0 +1,67 @@
+package sherlock
+
+import (
+	"fmt"
+)
+
+type annotation struct {
+	msg string
+	err error
+}
+
+func (a *annotation) Error() string {
+	return a.msg + ": " + a.err.Error()
+}
+
+func (a *annotation) Unwrap() error {
+	return a.err
+}
+
+// Annotate adds context to an error as it passes through a function. It must be
+// deferred, and prepends msg to any error thrown through the deferring
+// function, so that the error finally caught reads like
+// "loading user 42: opening profile: file not found". If the function instead
+// returns normally with a non-nil error in err, that error is annotated. err
+// may be nil for functions that do not return an error.
+//
+// Annotations do not affect which errors Catch matches.
+func Annotate(err *error, msg string) {
+	annotate(recover(), err, msg)
+}
+
+// Annotatef is like Annotate but formats its message according to a format
+// specifier.
+func Annotatef(err *error, format string, args ...interface{}) {
+	annotate(recover(), err, fmt.Sprintf(format, args...))
+}
+
+func annotate(r interface{}, err *error, msg string) {
+	if r == nil {
+		if err != nil && *err != nil {
+			*err = &annotation{msg: msg, err: *err}
+		}
+		return
+	}
+	if x, ok := r.(*report); ok {
+		x.err = &annotation{msg: msg, err: x.err}
+	}
+	panic(r)
+}
+
+// unannotate strips any annotations, fields, operations and info from err.
+func unannotate(err error) error {
+	for {
+		switch x := err.(type) {
+		case *annotation:
+			err = x.err
+		case *fielded:
+			err = x.err
+		case *opError:
+			err = x.err
+		case *Info:
+			err = x.err
+		default:
+			return err
+		}
+	}
+}
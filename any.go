@@ -0,0 +1,43 @@
+package sherlock
+
+import "errors"
+
+var soft table[bool]
+
+// RegisterSoft marks each of errs as soft, meaning that Any should move on to
+// its next candidate rather than fail when a candidate fails with one of them,
+// as with a cache miss.
+func RegisterSoft(errs ...error) {
+	for _, err := range errs {
+		soft.set(err, true)
+	}
+}
+
+// Any runs fns in order and returns the result of the first to succeed, for
+// fallback chains such as cache, then database, then a remote service. A
+// candidate failing with a soft error, registered with RegisterSoft, is
+// passed over. Any other error is returned immediately. Errors thrown by a
+// candidate are caught as well as returned ones. If every candidate fails
+// softly, their errors are returned joined together.
+func Any[T any](fns ...func() (T, error)) (T, error) {
+	var errs []error
+	for _, fn := range fns {
+		v, err := candidate(fn)
+		if err == nil {
+			return v, nil
+		}
+		if ok, _ := soft.lookup(err); !ok {
+			var zero T
+			return zero, err
+		}
+		errs = append(errs, err)
+	}
+	var zero T
+	return zero, errors.Join(errs...)
+}
+
+func candidate[T any](fn func() (T, error)) (v T, err error) {
+	defer CatchAll(&err)
+	v, err = fn()
+	return v, classify(err)
+}
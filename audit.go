@@ -0,0 +1,90 @@
+package sherlock
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Action describes what sherlock did with a recovered panic.
+type Action string
+
+const (
+	// ActionCaught means the error was recovered and handed to the caller.
+	ActionCaught Action = "caught"
+	// ActionRethrown means the error did not match and the panic continued.
+	ActionRethrown Action = "rethrown"
+	// ActionUnexpected means the panic was considered to be a bug.
+	ActionUnexpected Action = "unexpected"
+	// ActionObserved means the error was passed to Ok, and so was never
+	// thrown, but left to the caller to handle.
+	ActionObserved Action = "observed"
+)
+
+// AuditEvent records a single error handling decision.
+type AuditEvent struct {
+	Time    time.Time
+	Package string // the package the error was thrown from, if known
+	Err     error  // the error that was thrown
+	Result  error  // the error handed to the caller, or nil
+	Action  Action
+	// CorrelationID is the correlation ID the error was thrown or caught
+	// with, as described by SetCorrelationKey.
+	CorrelationID string
+}
+
+var audit struct {
+	sync.Mutex
+	events []AuditEvent
+	next   int
+	full   bool
+}
+
+// SetAuditSize enables the audit trail, keeping the last n events in memory.
+// A size of zero disables the audit trail, which is the default. Changing the
+// size discards any events already recorded.
+func SetAuditSize(n int) {
+	audit.Lock()
+	audit.events = make([]AuditEvent, n)
+	audit.next = 0
+	audit.full = false
+	audit.Unlock()
+}
+
+// AuditLog returns the recorded audit trail, oldest event first.
+func AuditLog() []AuditEvent {
+	audit.Lock()
+	defer audit.Unlock()
+	var events []AuditEvent
+	if audit.full {
+		events = append(events, audit.events[audit.next:]...)
+	}
+	return append(events, audit.events[:audit.next]...)
+}
+
+func record(r interface{}, result error, action Action) {
+	e := AuditEvent{Time: timestamp(time.Now()), Result: result, Action: action}
+	switch x := r.(type) {
+	case *report:
+		e.Package = x.pkg
+		e.Err = x.err
+		e.CorrelationID = x.id
+	case error:
+		e.Err = x
+	default:
+		e.Err = fmt.Errorf("%v", r)
+	}
+	e.Err, e.Result = redactError(e.Err), redactError(e.Result)
+	publish(e)
+	audit.Lock()
+	defer audit.Unlock()
+	if len(audit.events) == 0 {
+		return
+	}
+	audit.events[audit.next] = e
+	audit.next++
+	if audit.next == len(audit.events) {
+		audit.next = 0
+		audit.full = true
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !js
+
+package sherlock
+
+import "os"
+
+// defaultBackend returns the backend diagnostics are written to unless
+// SetBackend is used.
+func defaultBackend() Backend {
+	return WriterBackend(os.Stderr)
+}
@@ -0,0 +1,55 @@
+package sherlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Barrier runs fn within a full recovery boundary, for host applications
+// running plugins or other code they do not trust. Any panic raised by fn is
+// returned as an error after passing through the registered classifiers: the
+// thrown error for sherlock panics, and a *PanicError for any other panic,
+// which is also reported as a bug.
+func Barrier(fn func()) error {
+	defer traceRegion(context.Background(), "Barrier").End()
+	_, err := guard(func() error {
+		fn()
+		return nil
+	})
+	if _, ok := err.(*PanicError); ok {
+		err = classify(err)
+	}
+	return err
+}
+
+// BarrierGo is like Barrier, but also covers the goroutines fn starts through
+// the given Supervisor, which never restarts them. It waits for all of them to
+// finish, and returns the errors of fn and of each goroutine joined together.
+func BarrierGo(ctx context.Context, fn func(ctx context.Context, sup *Supervisor)) error {
+	defer traceRegion(ctx, "BarrierGo").End()
+	var mu sync.Mutex
+	var errs []error
+	sup := &Supervisor{
+		Restart: RestartNever,
+		Events: func(e ChildEvent) {
+			if e.State == ChildExited && e.Err != nil {
+				mu.Lock()
+				errs = append(errs, e.Err)
+				mu.Unlock()
+			}
+		},
+	}
+	err := Barrier(func() { fn(ctx, sup) })
+	sup.Wait()
+	if err != nil {
+		errs = append([]error{err}, errs...)
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,110 @@
+package sherlock
+
+import (
+	"sync"
+	"time"
+)
+
+var batch struct {
+	sync.Mutex
+	size    int
+	entries []Entry
+	stop    chan struct{}
+	done    chan struct{}
+	// flushing serialises flushes, so that entries reach the backend in order.
+	flushing sync.Mutex
+}
+
+// SetBatching buffers diagnostics and writes them to the backend in batches of
+// up to size entries, at least once every interval, rather than synchronously
+// as each is raised. Entries of SeverityError and above are still written
+// straight away, along with everything buffered before them, as they often
+// precede the process crashing. A size of zero disables batching, which is the
+// default, after flushing anything buffered.
+//
+// Programs that enable batching should defer Close in main so that nothing is
+// lost on shutdown. Main does this itself.
+func SetBatching(size int, interval time.Duration) {
+	Close()
+	if size <= 0 {
+		return
+	}
+	batch.Lock()
+	batch.size = size
+	if interval > 0 {
+		batch.stop = make(chan struct{})
+		batch.done = make(chan struct{})
+		go tick(interval, batch.stop, batch.done)
+	}
+	batch.Unlock()
+}
+
+func tick(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			Flush()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Flush writes any buffered diagnostics to the backend.
+func Flush() {
+	batch.flushing.Lock()
+	defer batch.flushing.Unlock()
+	batch.Lock()
+	entries := batch.entries
+	batch.entries = nil
+	batch.Unlock()
+	if len(entries) == 0 {
+		return
+	}
+	output.Lock()
+	b := output.b
+	output.Unlock()
+	if b == nil {
+		return
+	}
+	for _, e := range entries {
+		send(b, e)
+	}
+}
+
+// Close flushes any buffered diagnostics, disables batching, and saves the
+// counters if SetStatsFile is in use.
+func Close() {
+	batch.Lock()
+	stop, done := batch.stop, batch.done
+	batch.size = 0
+	batch.stop = nil
+	batch.done = nil
+	batch.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	Flush()
+	saveStats(true)
+}
+
+// batched buffers e if batching is enabled, and reports whether it did. It
+// flushes the buffer once it is full, or once e is severe enough.
+func batched(e Entry) bool {
+	batch.Lock()
+	if batch.size == 0 {
+		batch.Unlock()
+		return false
+	}
+	batch.entries = append(batch.entries, e)
+	full := len(batch.entries) >= batch.size || e.Severity.atLeast(SeverityError)
+	batch.Unlock()
+	if full {
+		Flush()
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package sherlock
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Handle throws errors attributed to a package resolved once, by Bind, rather
+// than on every call. Its methods behave exactly as the functions of the same
+// names, but skip walking the stack to find the calling package, which makes
+// them suited to hot paths.
+type Handle struct {
+	pkg string
+}
+
+// Bind resolves the calling package, the first outside sherlock that is not a
+// helper, and returns a Handle that throws errors on its behalf. It is intended to be called once, when initialising a package
+// level variable:
+//
+//	var sh = sherlock.Bind()
+//
+// Registrations are not scoped to packages, so Handle has no Register methods;
+// the package level Register functions apply to errors thrown by a Handle.
+func Bind() *Handle {
+	return &Handle{pkg: caller()}
+}
+
+// Assert is the Handle equivalent of Assert.
+func (h *Handle) Assert(condition bool, err error) {
+	if condition {
+		return
+	}
+	raise(nil, assertion(err), h.pkg)
+}
+
+// Check is the Handle equivalent of Check.
+func (h *Handle) Check(args ...interface{}) {
+	if len(args) == 0 || args[len(args)-1] != nil || atomic.LoadInt32(intercepts) != 0 {
+		h.check(args)
+	}
+}
+
+// check is the slow path of Check, as check is for the package level Check.
+//
+//go:noinline
+func (h *Handle) check(args []interface{}) {
+	checkIn(nil, args, h.pkg, 3)
+}
+
+// CheckCtx is the Handle equivalent of CheckCtx.
+func (h *Handle) CheckCtx(ctx context.Context) {
+	if err := ctxErr(ctx); err != nil {
+		raise(ctx, overlaid(ctx, err), h.pkg)
+	}
+}
+
+// Throw is the Handle equivalent of Throw.
+func (h *Handle) Throw(err error) {
+	raise(nil, err, h.pkg)
+}
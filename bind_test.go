@@ -0,0 +1,25 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestAssertWithoutError(t *testing.T) {
+	h := sherlock.Bind()
+	for name, fn := range map[string]func(){
+		"Assert":        func() { sherlock.Assert(false, nil) },
+		"Handle.Assert": func() { h.Assert(false, nil) },
+	} {
+		err := func() (err error) {
+			defer sherlock.CatchAll(&err)
+			fn()
+			return nil
+		}()
+		if !errors.Is(err, sherlock.ErrAssertion) {
+			t.Errorf("%v: got %v, want %v", name, err, sherlock.ErrAssertion)
+		}
+	}
+}
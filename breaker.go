@@ -0,0 +1,157 @@
+package sherlock
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Breaker.Do instead of running the function
+// while a circuit is open. It is registered with the code "circuit_open" and
+// HTTP status 503 Service Unavailable.
+var ErrCircuitOpen = errors.New("circuit open")
+
+func init() {
+	RegisterCodeMapping(ErrCircuitOpen, "circuit_open")
+	RegisterHTTPStatus(ErrCircuitOpen, 503)
+}
+
+// Breaker is a circuit breaker that counts failures by the category of their
+// error, which is the error's code unless Category is set. Once Threshold
+// failures of one category are seen within Window the circuit for that
+// category opens, and calls fail fast with ErrCircuitOpen until Cooldown has
+// passed. A single call is then let through as a probe, closing the circuit if
+// it does not fail with the same category and reopening it otherwise; if the
+// cooldowns of several circuits have passed, the one call probes them all. A
+// probe that panics leaves its circuits open for the next call to probe. Errors
+// without a category are never counted.
+//
+// A zero Threshold means 5, a zero Cooldown means 30 seconds, and a zero Window
+// means failures are counted until the circuit opens. A Breaker must not be
+// copied after first use.
+type Breaker struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+	Category  func(error) string
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures []time.Time
+	opened   time.Time
+	probing  bool
+}
+
+// Do runs fn unless a circuit is open, and records the outcome. Errors thrown
+// by fn are caught as well as returned ones.
+func (b *Breaker) Do(fn func() error) error {
+	probes, err := b.admit(time.Now())
+	if err != nil {
+		return err
+	}
+	observed := false
+	defer func() {
+		if !observed {
+			b.abandon(probes)
+		}
+	}()
+	err = attempt(fn)
+	observed = true
+	b.observe(time.Now(), probes, err)
+	return err
+}
+
+// admit returns an error if the call must fail fast, and otherwise the
+// categories probed by the call. Every circuit whose cooldown has passed is
+// probed by the same call, as the call cannot be let through for one open
+// circuit and failed fast for another.
+func (b *Breaker) admit(now time.Time) ([]string, error) {
+	cooldown := b.Cooldown
+	if cooldown == 0 {
+		cooldown = 30 * time.Second
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var probes []string
+	for cat, c := range b.circuits {
+		if c.opened.IsZero() {
+			continue
+		}
+		if c.probing || now.Sub(c.opened) < cooldown {
+			return nil, fmt.Errorf("%w: %v", ErrCircuitOpen, cat)
+		}
+		probes = append(probes, cat)
+	}
+	for _, cat := range probes {
+		b.circuits[cat].probing = true
+	}
+	return probes, nil
+}
+
+// abandon releases the circuits probed by a call that panicked, so that the
+// next call probes them again.
+func (b *Breaker) abandon(probes []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cat := range probes {
+		if c := b.circuits[cat]; c != nil {
+			c.probing = false
+		}
+	}
+}
+
+func (b *Breaker) observe(now time.Time, probes []string, err error) {
+	cat := ""
+	if err != nil {
+		if b.Category != nil {
+			cat = b.Category(err)
+		} else {
+			cat = Code(err)
+		}
+	}
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = 5
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, probe := range probes {
+		if probe == cat {
+			c := b.circuits[probe]
+			c.probing = false
+			c.opened = now
+		} else {
+			delete(b.circuits, probe)
+		}
+	}
+	if cat == "" {
+		return
+	}
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	c := b.circuits[cat]
+	if c == nil {
+		c = new(circuit)
+		b.circuits[cat] = c
+	}
+	if !c.opened.IsZero() {
+		return
+	}
+	if b.Window > 0 {
+		i := 0
+		for i < len(c.failures) && now.Sub(c.failures[i]) > b.Window {
+			i++
+		}
+		c.failures = c.failures[i:]
+	}
+	c.failures = append(c.failures, now)
+	if len(c.failures) >= threshold {
+		c.failures = nil
+		c.opened = now
+	}
+}
@@ -0,0 +1,56 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+func newTestBreaker() *sherlock.Breaker {
+	return &sherlock.Breaker{
+		Threshold: 1,
+		Cooldown:  time.Millisecond,
+		Category:  func(err error) string { return err.Error() },
+	}
+}
+
+func fail(msg string) func() error {
+	return func() error { return errors.New(msg) }
+}
+
+func TestBreakerOpens(t *testing.T) {
+	b := newTestBreaker()
+	b.Do(fail("a"))
+	if err := b.Do(func() error { return nil }); !errors.Is(err, sherlock.ErrCircuitOpen) {
+		t.Fatalf("got %v, want %v", err, sherlock.ErrCircuitOpen)
+	}
+}
+
+func TestBreakerProbesEveryDueCircuit(t *testing.T) {
+	b := newTestBreaker()
+	b.Do(fail("a"))
+	b.Do(fail("b"))
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		ran := false
+		if err := b.Do(func() error { ran = true; return nil }); err != nil || !ran {
+			t.Fatalf("call %v: got %v, ran %v", i, err, ran)
+		}
+	}
+}
+
+func TestBreakerProbePanics(t *testing.T) {
+	b := newTestBreaker()
+	b.Do(fail("a"))
+	time.Sleep(5 * time.Millisecond)
+	func() {
+		defer func() { recover() }()
+		b.Do(func() error { panic("probe panicked") })
+	}()
+	ran := false
+	if err := b.Do(func() error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("got %v, ran %v", err, ran)
+	}
+}
@@ -0,0 +1,103 @@
+package sherlock
+
+import (
+	"sync"
+	"time"
+)
+
+// ErrorBudget tracks the errors of one category against the number allowed
+// within a sliding window, so that a service can shed load or disable features
+// once its errors exceed what it can afford. Once tracked with TrackBudget it
+// counts every error thrown whose code is Category, or every error thrown if
+// Category is empty, as well as every panic not raised by sherlock.
+//
+// If OnBurn is set it is called, at most once per Fast, whenever the errors
+// seen within the last Fast would use up the budget before the end of Window,
+// that is when they exceed Allowed scaled down to Fast. It is called on the
+// goroutine that raised the error, and must not block.
+type ErrorBudget struct {
+	Category string
+	Allowed  int
+	Window   time.Duration
+	Fast     time.Duration
+	OnBurn   func(b *ErrorBudget)
+
+	mu     sync.Mutex
+	times  []time.Time
+	burned time.Time
+}
+
+var budgets struct {
+	sync.RWMutex
+	list []*ErrorBudget
+}
+
+// TrackBudget starts b counting errors.
+func TrackBudget(b *ErrorBudget) {
+	budgets.Lock()
+	budgets.list = append(budgets.list, b)
+	budgets.Unlock()
+}
+
+// Remaining returns the number of errors that may still be seen within the
+// window before the budget is exhausted.
+func (b *ErrorBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trim(time.Now())
+	if n := b.Allowed - len(b.times); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Exhausted reports whether the budget has been used up within the window.
+func (b *ErrorBudget) Exhausted() bool {
+	return b.Remaining() == 0
+}
+
+func (b *ErrorBudget) trim(now time.Time) {
+	i := 0
+	for i < len(b.times) && now.Sub(b.times[i]) > b.Window {
+		i++
+	}
+	b.times = b.times[i:]
+}
+
+func (b *ErrorBudget) observe(now time.Time) {
+	b.mu.Lock()
+	b.trim(now)
+	b.times = append(b.times, now)
+	burn := false
+	if b.OnBurn != nil && b.Fast > 0 && b.Window > 0 && now.Sub(b.burned) >= b.Fast {
+		n := 0
+		for i := len(b.times) - 1; i >= 0 && now.Sub(b.times[i]) <= b.Fast; i-- {
+			n++
+		}
+		if float64(n) > float64(b.Allowed)*float64(b.Fast)/float64(b.Window) {
+			b.burned = now
+			burn = true
+		}
+	}
+	b.mu.Unlock()
+	if burn {
+		b.OnBurn(b)
+	}
+}
+
+// spend counts err against every tracked budget it falls into.
+func spend(err error) {
+	budgets.RLock()
+	list := budgets.list
+	budgets.RUnlock()
+	if len(list) == 0 {
+		return
+	}
+	now := time.Now()
+	code := Code(err)
+	for _, b := range list {
+		if b.Category == "" || b.Category == code {
+			b.observe(now)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package sherlock
+
+import (
+	"strings"
+	"sync"
+)
+
+var messageKeys table[string]
+
+var catalog struct {
+	sync.RWMutex
+	m map[string]map[string]string // lang → key → text
+}
+
+// RegisterMessageKey registers key as the catalog key for err's user-facing
+// message.
+func RegisterMessageKey(err error, key string) {
+	messageKeys.set(err, key)
+}
+
+// RegisterTranslation adds the text for key in the language lang to the
+// message catalog.
+func RegisterTranslation(lang, key, text string) {
+	catalog.Lock()
+	if catalog.m == nil {
+		catalog.m = make(map[string]map[string]string)
+	}
+	if catalog.m[lang] == nil {
+		catalog.m[lang] = make(map[string]string)
+	}
+	catalog.m[lang][key] = text
+	catalog.Unlock()
+}
+
+// ErrorMessage returns the user-facing message for err in the language lang.
+// If there is no translation for lang, its base language is tried, so that
+// "en-GB" falls back to "en". If neither exists PublicMessage is returned.
+func ErrorMessage(err error, lang string) string {
+	key, ok := messageKeys.lookup(err)
+	if !ok {
+		return PublicMessage(err)
+	}
+	catalog.RLock()
+	defer catalog.RUnlock()
+	if text, ok := catalog.m[lang][key]; ok {
+		return text
+	}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		if text, ok := catalog.m[lang[:i]][key]; ok {
+			return text
+		}
+	}
+	return PublicMessage(err)
+}
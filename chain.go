@@ -0,0 +1,40 @@
+package sherlock
+
+// Chain returns err followed by every error it wraps, outermost first,
+// including sherlock's own annotation and field wrappers. Errors that wrap
+// several errors are followed depth first.
+func Chain(err error) []error {
+	var chain []error
+	walk(err, func(e error) bool {
+		chain = append(chain, e)
+		return false
+	})
+	return chain
+}
+
+// RootCause returns the innermost error wrapped by err, which is the error
+// originally thrown before any annotations or other wrapping were added. If
+// the chain reaches an error that wraps several errors, that error is
+// returned.
+func RootCause(err error) error {
+	root := err
+	walk(err, func(e error) bool {
+		root = e
+		_, multi := e.(interface{ Unwrap() []error })
+		return multi
+	})
+	return root
+}
+
+// HasCategory reports whether any error in err's chain has the code cat, as
+// returned by Code. Codes are the categories that Breaker and ErrorBudget count
+// errors by, so HasCategory matches an error wrapped with a different code of
+// its own, or annotated after it was caught, by the code it was thrown with.
+func HasCategory(err error, cat string) bool {
+	if cat == "" {
+		return false
+	}
+	return walk(err, func(e error) bool {
+		return Code(e) == cat
+	})
+}
@@ -0,0 +1,20 @@
+package sherlock_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestHasCategory(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	sherlock.RegisterCodeMapping(errQuota, "quota")
+	err := &sherlock.Coded{Code: "upstream", Err: fmt.Errorf("calling billing: %w", errQuota)}
+	for cat, want := range map[string]bool{"upstream": true, "quota": true, "other": false, "": false} {
+		if got := sherlock.HasCategory(err, cat); got != want {
+			t.Errorf("HasCategory(err, %q) = %v", cat, got)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package sherlock
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+)
+
+// A Classifier recognises errors that cannot be registered directly, such as
+// dynamically created errors of a particular type, and returns the registered
+// sentinel that err should be treated as. It returns nil for errors it does not
+// recognise.
+type Classifier func(err error) error
+
+var classifiers struct {
+	sync.RWMutex
+	fns []Classifier
+	// handles holds, for each of fns, the handle of the PushOverrides that
+	// added it, or 0 if it was added by RegisterClassifier.
+	handles []uint64
+}
+
+// RegisterClassifier adds fn to the classifiers consulted whenever an error is
+// thrown. The thrown error is wrapped so that it matches the sentinel returned
+// by the first classifier to recognise it, in Catch, with errors.Is, and in
+// every registration lookup, while its message and chain are unchanged.
+func RegisterClassifier(fn Classifier) {
+	classifiers.Lock()
+	classifiers.fns = append(classifiers.fns, fn)
+	classifiers.handles = append(classifiers.handles, 0)
+	classifiers.Unlock()
+}
+
+type classified struct {
+	err      error
+	sentinel error
+	domain   string // the domain whose overlay classified err, if any
+}
+
+func (c *classified) Error() string {
+	return c.err.Error()
+}
+
+// Is reports whether target is the sentinel, or is matched by the sentinel, so
+// that sentinels can form families.
+func (c *classified) Is(target error) bool {
+	return target == c.sentinel || is(c.sentinel, target)
+}
+
+func (c *classified) Unwrap() error {
+	return c.err
+}
+
+// Known is implemented by errors that need no classification, such as those of
+// libraries that cooperate with sherlock by registering their own sentinels.
+// Errors implementing Known are not passed to any classifier, global or in an
+// overlay, which saves consulting expensive matchers for errors that could
+// never need them.
+type Known interface {
+	error
+	SherlockKnown()
+}
+
+// classify wraps err to match the sentinel of the first classifier that
+// recognises it, or failing that the registered error SetFuzzyMatch finds for
+// it. Errors that are not recognised, or that have already been classified,
+// such as by an overlay, are returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch unannotate(err).(type) {
+	case Known, *classified:
+		return err
+	}
+	classifiers.RLock()
+	defer classifiers.RUnlock()
+	for _, fn := range classifiers.fns {
+		if sentinel := consult(fn, err); sentinel != nil {
+			return &classified{err: err, sentinel: sentinel}
+		}
+	}
+	if sentinel := nearest(err); sentinel != nil {
+		return &classified{err: err, sentinel: sentinel}
+	}
+	return err
+}
+
+// consult returns the sentinel fn recognises err as. A classifier that panics
+// is reported and treated as not recognising err, so that a misbehaving rule
+// cannot replace the error being classified with a panic of its own.
+func consult(fn Classifier, err error) (sentinel error) {
+	defer func() {
+		if r := recover(); r != nil {
+			sentinel = nil
+			misbehaved("classifier "+nameOf(fn), r)
+		}
+	}()
+	return fn(err)
+}
+
+// misbehaved reports a panic recovered from code sherlock called while looking
+// up or classifying an error.
+func misbehaved(what string, r interface{}) {
+	emit(Entry{
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("sherlock: %v panicked: %v", what, describe(r)),
+		Stack:    stacktrace(),
+	})
+}
+
+func nameOf(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "?"
+}
+
+// Matcher is an engine that recognises errors, such as one matching the error
+// details of an RPC framework or the errors of a vendor SDK. Match returns the
+// registered sentinel err should be treated as, and whether it recognised err.
+type Matcher interface {
+	Match(err error) (sentinel error, ok bool)
+}
+
+// RegisterMatcher adds m to the classifiers consulted whenever an error is
+// thrown, as with RegisterClassifier.
+func RegisterMatcher(m Matcher) {
+	RegisterClassifier(func(err error) error {
+		if sentinel, ok := m.Match(err); ok {
+			return sentinel
+		}
+		return nil
+	})
+}
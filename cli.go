@@ -0,0 +1,28 @@
+package sherlock
+
+// RunE wraps a command function, such as a cobra RunE, with a CatchAll
+// boundary so that commands can use Check and Throw directly. Errors with a
+// registered public message are returned with that message, so that the CLI
+// shows users the friendly text, while still wrapping the original error. The
+// command type is generic so that this package does not depend on cobra.
+//
+//	cmd := &cobra.Command{
+//		RunE: sherlock.RunE(func(cmd *cobra.Command, args []string) error {
+//			sherlock.Check(os.Remove(args[0]))
+//			return nil
+//		}),
+//	}
+func RunE[C any](fn func(cmd C, args []string) error) func(C, []string) error {
+	return func(cmd C, args []string) (err error) {
+		defer func() {
+			if err == nil {
+				return
+			}
+			if msg, ok := publicMessages.lookup(err); ok {
+				err = &Coded{Code: Code(err), Message: msg, Public: msg, Err: err}
+			}
+		}()
+		defer CatchAll(&err)
+		return fn(cmd, args)
+	}
+}
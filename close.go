@@ -0,0 +1,43 @@
+package sherlock
+
+import (
+	"errors"
+	"io"
+)
+
+// CheckedClose closes c and merges any error from doing so into err, after
+// passing it through the registered classifiers. It is intended to be
+// deferred by functions that write through c, where a failed Close can mean
+// the data was lost.
+//
+//	defer sherlock.CheckedClose(f, &err)
+//
+// If err already holds an error the two are joined, so that an earlier
+// failure is never overwritten by a later Close.
+func CheckedClose(c io.Closer, err *error) {
+	cerr := c.Close()
+	if cerr == nil {
+		return
+	}
+	cerr = classify(cerr)
+	if *err == nil {
+		*err = cerr
+	} else {
+		*err = errors.Join(*err, cerr)
+	}
+}
+
+// TryClose closes c, reporting any error as a warning rather than returning
+// it. It suits deferred closes of resources that were only read from.
+func TryClose(c io.Closer) {
+	err := c.Close()
+	if err == nil {
+		return
+	}
+	err = classify(err)
+	diagnose(Entry{
+		Severity: SeverityWarning,
+		Message:  "sherlock: close: " + err.Error(),
+		Hint:     Hint(err),
+	})
+}
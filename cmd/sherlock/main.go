@@ -0,0 +1,128 @@
+/*
+Sherlock tests error messages against an exported catalog of registered
+errors, to debug how errors will be treated before deploying changes to their
+registrations.
+
+	sherlock catalog.json
+
+The catalog is the JSON written by sherlock.WriteCatalog with
+sherlock.CatalogJSON, of any version. Each line read from standard input is taken as an error
+message and matched against the catalog: exactly, or as a registered error that
+has been wrapped with further context, such as "db.SaveUser: not found" for the
+registered error "not found". Every entry that matches is printed, so that
+ambiguous messages are apparent. Lookups in a running program also match by
+identity and errors.Is, which a message alone cannot show.
+*/
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type entry struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	HTTPStatus int    `json:"http_status"`
+	Public     string `json:"public"`
+	Hint       string `json:"hint"`
+	Severity   string `json:"severity"`
+	Retryable  bool   `json:"retryable"`
+	MessageKey string `json:"message_key"`
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: sherlock catalog.json\n")
+		os.Exit(2)
+	}
+	entries, err := load(os.Args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sherlock: %v\n", err)
+		os.Exit(1)
+	}
+	repl(os.Stdin, os.Stdout, entries)
+}
+
+func load(path string) ([]entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Errors []entry `json:"errors"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "[") {
+		err = json.Unmarshal(b, &file.Errors)
+	} else {
+		err = json.Unmarshal(b, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return file.Errors, nil
+}
+
+func repl(r io.Reader, w io.Writer, entries []entry) {
+	fmt.Fprintf(w, "%v registered errors; enter a message per line\n> ", len(entries))
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		msg := strings.TrimSpace(sc.Text())
+		if msg != "" {
+			report(w, msg, entries)
+		}
+		fmt.Fprint(w, "> ")
+	}
+	fmt.Fprintln(w)
+}
+
+func report(w io.Writer, msg string, entries []entry) {
+	n := 0
+	for _, e := range entries {
+		how := match(msg, e.Error)
+		if how == "" {
+			continue
+		}
+		n++
+		fmt.Fprintf(w, "%v match for %q\n", how, e.Error)
+		fmt.Fprintf(w, "\tcode: %v\n\tstatus: %v\n\tpublic: %v\n\tseverity: %v\n\tretryable: %v\n",
+			orNone(e.Code), e.HTTPStatus, orNone(e.Public), e.Severity, e.Retryable)
+		if e.Hint != "" {
+			fmt.Fprintf(w, "\thint: %v\n", e.Hint)
+		}
+		if e.MessageKey != "" {
+			fmt.Fprintf(w, "\tmessage key: %v\n", e.MessageKey)
+		}
+	}
+	switch n {
+	case 0:
+		fmt.Fprintln(w, "no match: the error would be treated as unregistered")
+	case 1:
+	default:
+		fmt.Fprintf(w, "%v entries match; the first registered takes precedence\n", n)
+	}
+}
+
+// match reports how msg matches the registered error message registered: as
+// "exact", as "wrapped" if registered is the innermost part of a chain of
+// "context: error" messages, or not at all with "".
+func match(msg, registered string) string {
+	switch {
+	case msg == registered:
+		return "exact"
+	case strings.HasSuffix(msg, ": "+registered):
+		return "wrapped"
+	}
+	return ""
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
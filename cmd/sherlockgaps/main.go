@@ -0,0 +1,270 @@
+/*
+Sherlockgaps reports sentinel errors that a package declares, or can return
+from the packages it calls, but never registers with sherlock.
+
+	sherlockgaps [dir ...]
+
+Each directory is scanned for package-level declarations of the form
+
+	var ErrX = errors.New(...)
+
+and for calls to any function or method whose name begins with Register. A
+sentinel that is never passed to one of those calls is reported, since it will
+be treated as an unregistered error when it reaches a boundary.
+
+The package is also type-checked, and every call it makes to a function in
+another package that returns an error is taken to be able to return any of
+that package's exported error variables, such as sql.ErrNoRows. Those that are
+never registered are reported at the first such call. Packages are imported
+from source, so the directory must be buildable for calls to be followed.
+
+Test files are ignored. The exit status is 1 if any gaps were found.
+*/
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+)
+
+const importPath = "github.com/alankm/sherlock"
+
+type sentinel struct {
+	name string
+	pos  token.Position
+	// from is the path of the package the sentinel is declared in, if it is
+	// not the scanned package.
+	from string
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	found := false
+	for _, dir := range dirs {
+		gaps, err := scan(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sherlockgaps: %v\n", err)
+			os.Exit(2)
+		}
+		for _, s := range gaps {
+			if s.from != "" {
+				fmt.Printf("%v: calls into %v can return %v, which is never registered\n", s.pos, s.from, s.name)
+			} else {
+				fmt.Printf("%v: %v is never registered\n", s.pos, s.name)
+			}
+			found = true
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+func scan(dir string) ([]sentinel, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	imp := importer.ForCompiler(fset, "source", nil)
+	var gaps []sentinel
+	for _, pkg := range pkgs {
+		var files []*ast.File
+		for _, f := range pkg.Files {
+			files = append(files, f)
+		}
+		info := &types.Info{Uses: make(map[*ast.Ident]types.Object)}
+		conf := types.Config{Importer: imp, Error: func(error) {}}
+		self, _ := conf.Check(pkg.Name, fset, files, info)
+
+		declared := make(map[string]sentinel)
+		registered := make(map[string]bool)
+		registeredObjs := make(map[types.Object]bool)
+		for _, f := range files {
+			for _, s := range sentinels(fset, f) {
+				declared[s.name] = s
+			}
+			ast.Inspect(f, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || !strings.HasPrefix(funcName(call.Fun), "Register") {
+					return true
+				}
+				for _, arg := range call.Args {
+					ast.Inspect(arg, func(n ast.Node) bool {
+						if id, ok := n.(*ast.Ident); ok {
+							registered[id.Name] = true
+							if obj := info.Uses[id]; obj != nil {
+								registeredObjs[obj] = true
+							}
+						}
+						return true
+					})
+				}
+				return true
+			})
+		}
+		for name, s := range declared {
+			if !registered[name] {
+				gaps = append(gaps, s)
+			}
+		}
+		for _, s := range returnable(fset, files, self, info) {
+			if !registeredObjs[s.obj] {
+				gaps = append(gaps, s.sentinel)
+			}
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		a, b := gaps[i].pos, gaps[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return gaps[i].name < gaps[j].name
+	})
+	return gaps, nil
+}
+
+// sentinels returns the package-level variables in f that are initialised by
+// errors.New or fmt.Errorf.
+func sentinels(fset *token.FileSet, f *ast.File) []sentinel {
+	var out []sentinel
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if i >= len(vs.Values) || name.Name == "_" {
+					continue
+				}
+				call, ok := vs.Values[i].(*ast.CallExpr)
+				if !ok {
+					continue
+				}
+				switch qualifiedName(call.Fun) {
+				case "errors.New", "fmt.Errorf":
+					out = append(out, sentinel{name: name.Name, pos: fset.Position(name.Pos())})
+				}
+			}
+		}
+	}
+	return out
+}
+
+type foreignSentinel struct {
+	sentinel
+	obj types.Object
+}
+
+// returnable returns the exported error variables of every other package that
+// files call an error-returning function of, each positioned at the first
+// such call.
+func returnable(fset *token.FileSet, files []*ast.File, self *types.Package, info *types.Info) []foreignSentinel {
+	var out []foreignSentinel
+	seen := make(map[*types.Package]bool)
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn := callee(call.Fun, self, info)
+			if fn == nil || seen[fn.Pkg()] || !returnsError(fn) {
+				return true
+			}
+			seen[fn.Pkg()] = true
+			scope := fn.Pkg().Scope()
+			for _, name := range scope.Names() {
+				v, ok := scope.Lookup(name).(*types.Var)
+				if !ok || !v.Exported() || !types.Identical(v.Type(), errorType) {
+					continue
+				}
+				out = append(out, foreignSentinel{
+					sentinel: sentinel{
+						name: fn.Pkg().Name() + "." + name,
+						pos:  fset.Position(call.Pos()),
+						from: fn.Pkg().Path(),
+					},
+					obj: v,
+				})
+			}
+			return true
+		})
+	}
+	return out
+}
+
+// callee returns the function or method called by fun if it is declared in
+// another package whose errors may need registering, or nil. Sherlock itself
+// registers its own errors, and the errors package only constructs them.
+func callee(fun ast.Expr, self *types.Package, info *types.Info) *types.Func {
+	var id *ast.Ident
+	switch fn := fun.(type) {
+	case *ast.Ident:
+		id = fn
+	case *ast.SelectorExpr:
+		id = fn.Sel
+	default:
+		return nil
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg() == self {
+		return nil
+	}
+	path := fn.Pkg().Path()
+	if path == "errors" || path == importPath || strings.HasPrefix(path, importPath+"/") {
+		return nil
+	}
+	return fn
+}
+
+func returnsError(fn *types.Func) bool {
+	results := fn.Type().(*types.Signature).Results()
+	for i := 0; i < results.Len(); i++ {
+		if types.Identical(results.At(i).Type(), errorType) {
+			return true
+		}
+	}
+	return false
+}
+
+func funcName(fun ast.Expr) string {
+	switch fn := fun.(type) {
+	case *ast.Ident:
+		return fn.Name
+	case *ast.SelectorExpr:
+		return fn.Sel.Name
+	}
+	return ""
+}
+
+func qualifiedName(fun ast.Expr) string {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	return x.Name + "." + sel.Sel.Name
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const source = `package app
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrLocal = errors.New("local")
+	ErrKnown = errors.New("known")
+)
+
+func RegisterCode(err error, code string) {}
+
+func init() {
+	RegisterCode(ErrKnown, "known")
+	RegisterCode(sql.ErrNoRows, "not_found")
+}
+
+func ping(db *sql.DB) error {
+	return db.Ping()
+}
+`
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gaps, err := scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range gaps {
+		names = append(names, s.name)
+	}
+	want := []string{"ErrLocal", "sql.ErrConnDone", "sql.ErrTxDone"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("got gaps %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,388 @@
+/*
+Sherlockgen generates the code mappings for a package's sentinel errors.
+
+	//go:generate sherlockgen [-o sherlock_gen.go] [-func Install]
+
+Every package-level declaration of the form
+
+	var ErrNotFound = errors.New(...)
+
+in the current directory is registered with sherlock.RegisterCodeMapping,
+using the name without its Err prefix in snake case, "not_found" in this
+example, as the code.
+
+Exported types with an Error method, such as
+
+	type SyntaxError struct{ Line int }
+
+	func (e SyntaxError) Error() string { ... }
+
+cannot be registered as values, since every error of the type is a different
+value. For each one a sentinel is generated, registered with the type's name in
+snake case, "syntax_error" here, as its code, along with a classifier that uses
+errors.As to have every error of the type treated as that sentinel. Pointer
+receivers are handled as well, with *SyntaxError as the matched type.
+
+The registrations are written to an init function, or to an exported function
+of the given name if -func is set, so that the mappings stay in sync with the
+errors the package declares. Test files and the output file itself are ignored.
+
+With -catalog, the sentinels are generated from a JSON catalog written by
+sherlock.WriteCatalog instead, so that a rule set shared between services and
+the errors a package declares cannot drift apart:
+
+	//go:generate sherlockgen -catalog errors.json
+
+Each entry is declared as a sentinel named Err followed by its code in camel
+case, ErrNotFound for "not_found", or by its message if it has no code, and
+registered with the code, HTTP status, public message, hint, message key,
+severity and retryability the catalog gives it. Sentinels the package already
+declares under those names are registered rather than declared again.
+*/
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	out := flag.String("o", "sherlock_gen.go", "output file")
+	fn := flag.String("func", "", "name of the function to generate instead of init")
+	catalog := flag.String("catalog", "", "JSON catalog to generate sentinels from")
+	flag.Parse()
+	gen := generate
+	if *catalog != "" {
+		gen = func(dir, out, fn string) error { return generateCatalog(dir, out, fn, *catalog) }
+	}
+	if err := gen(".", *out, *fn); err != nil {
+		fmt.Fprintf(os.Stderr, "sherlockgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(dir, out, fn string) error {
+	pkg, err := parse(dir, out)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, f := range pkg.Files {
+		names = append(names, sentinels(f)...)
+	}
+	types := errorTypes(pkg.Files)
+	sort.Strings(names)
+	sort.Slice(types, func(i, j int) bool { return types[i].name < types[j].name })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by sherlockgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %v\n\n", pkg.Name)
+	if len(types) > 0 {
+		fmt.Fprintf(&b, "import (\n\t\"errors\"\n\n\t\"github.com/alankm/sherlock\"\n)\n\n")
+		fmt.Fprintf(&b, "var (\n")
+		for _, t := range types {
+			fmt.Fprintf(&b, "\t%v = errors.New(%q)\n", t.sentinel(), strings.ReplaceAll(code(t.name), "_", " "))
+		}
+		fmt.Fprintf(&b, ")\n\n")
+	} else {
+		fmt.Fprintf(&b, "import \"github.com/alankm/sherlock\"\n\n")
+	}
+	if fn == "" {
+		fmt.Fprintf(&b, "func init() {\n")
+	} else {
+		fmt.Fprintf(&b, "// %v registers the codes of the package's errors with sherlock.\n", fn)
+		fmt.Fprintf(&b, "func %v() {\n", fn)
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "\tsherlock.RegisterCodeMapping(%v, %q)\n", name, code(name))
+	}
+	for _, t := range types {
+		target := t.name
+		if t.pointer {
+			target = "*" + target
+		}
+		fmt.Fprintf(&b, "\tsherlock.RegisterCodeMapping(%v, %q)\n", t.sentinel(), code(t.name))
+		fmt.Fprintf(&b, "\tsherlock.RegisterClassifier(func(err error) error {\n")
+		fmt.Fprintf(&b, "\t\tvar target %v\n", target)
+		fmt.Fprintf(&b, "\t\tif errors.As(err, &target) {\n\t\t\treturn %v\n\t\t}\n", t.sentinel())
+		fmt.Fprintf(&b, "\t\treturn nil\n\t})\n")
+	}
+	fmt.Fprintf(&b, "}\n")
+	return write(dir, out, b.Bytes())
+}
+
+// parse parses the package in dir, leaving out its tests and the output file.
+func parse(dir, out string) (*ast.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(out)
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %v, found %v", dir, len(pkgs))
+	}
+	for _, p := range pkgs {
+		return p, nil
+	}
+	return nil, nil
+}
+
+func write(dir, out string, b []byte) error {
+	src, err := format.Source(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, out), src, 0644)
+}
+
+// entry is an entry of a catalog written by sherlock.WriteCatalog.
+type entry struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	HTTPStatus int    `json:"http_status"`
+	Public     string `json:"public"`
+	Hint       string `json:"hint"`
+	Severity   string `json:"severity"`
+	Retryable  bool   `json:"retryable"`
+	MessageKey string `json:"message_key"`
+}
+
+// sentinel returns the name of the sentinel generated for e.
+func (e entry) sentinel() string {
+	name := e.Code
+	if name == "" {
+		name = e.Error
+	}
+	var b strings.Builder
+	b.WriteString("Err")
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// readCatalog reads the entries of the catalog at path, in either the bare or
+// the versioned form.
+func readCatalog(path string) ([]entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Errors []entry `json:"errors"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "[") {
+		err = json.Unmarshal(b, &file.Errors)
+	} else {
+		err = json.Unmarshal(b, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return file.Errors, nil
+}
+
+func generateCatalog(dir, out, fn, path string) error {
+	pkg, err := parse(dir, out)
+	if err != nil {
+		return err
+	}
+	entries, err := readCatalog(filepath.Join(dir, path))
+	if err != nil {
+		return err
+	}
+	declared := make(map[string]bool)
+	for _, f := range pkg.Files {
+		for _, name := range sentinels(f) {
+			declared[name] = true
+		}
+	}
+	seen := make(map[string]bool)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by sherlockgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %v\n\n", pkg.Name)
+	fmt.Fprintf(&b, "import (\n\t\"errors\"\n\n\t\"github.com/alankm/sherlock\"\n)\n\n")
+	fmt.Fprintf(&b, "var (\n")
+	for _, e := range entries {
+		name := e.sentinel()
+		if seen[name] {
+			return fmt.Errorf("%v: entries %q and another both generate %v", path, e.Error, name)
+		}
+		seen[name] = true
+		if !declared[name] {
+			fmt.Fprintf(&b, "\t%v = errors.New(%q)\n", name, e.Error)
+		}
+	}
+	fmt.Fprintf(&b, ")\n\n")
+	if fn == "" {
+		fmt.Fprintf(&b, "func init() {\n")
+	} else {
+		fmt.Fprintf(&b, "// %v registers the package's errors with sherlock as %v does.\n", fn, filepath.Base(path))
+		fmt.Fprintf(&b, "func %v() {\n", fn)
+	}
+	for _, e := range entries {
+		name := e.sentinel()
+		if e.Code != "" {
+			fmt.Fprintf(&b, "\tsherlock.RegisterCodeMapping(%v, %q)\n", name, e.Code)
+		}
+		if e.HTTPStatus != 0 {
+			fmt.Fprintf(&b, "\tsherlock.RegisterHTTPStatus(%v, %v)\n", name, e.HTTPStatus)
+		}
+		if e.Public != "" {
+			fmt.Fprintf(&b, "\tsherlock.RegisterPublicMessage(%v, %q)\n", name, e.Public)
+		}
+		if e.Hint != "" {
+			fmt.Fprintf(&b, "\tsherlock.RegisterHint(%v, %q)\n", name, e.Hint)
+		}
+		if e.MessageKey != "" {
+			fmt.Fprintf(&b, "\tsherlock.RegisterMessageKey(%v, %q)\n", name, e.MessageKey)
+		}
+		switch e.Severity {
+		case "":
+		case "info", "warning", "error", "fatal":
+			fmt.Fprintf(&b, "\tsherlock.RegisterSeverity(%v, sherlock.Severity%v)\n", name, strings.ToUpper(e.Severity[:1])+e.Severity[1:])
+		default:
+			return fmt.Errorf("%v: unknown severity %q for %q", path, e.Severity, e.Error)
+		}
+		if e.Retryable {
+			fmt.Fprintf(&b, "\tsherlock.RegisterRetryable(%v)\n", name)
+		}
+	}
+	fmt.Fprintf(&b, "}\n")
+	return write(dir, out, b.Bytes())
+}
+
+// sentinels returns the names of the package-level variables in f that are
+// initialised by errors.New or fmt.Errorf.
+func sentinels(f *ast.File) []string {
+	var out []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if i >= len(vs.Values) || name.Name == "_" {
+					continue
+				}
+				call, ok := vs.Values[i].(*ast.CallExpr)
+				if !ok {
+					continue
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					continue
+				}
+				if x, ok := sel.X.(*ast.Ident); ok {
+					switch x.Name + "." + sel.Sel.Name {
+					case "errors.New", "fmt.Errorf":
+						out = append(out, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// errorType is an exported type with an Error method.
+type errorType struct {
+	name    string
+	pointer bool // whether Error has a pointer receiver
+}
+
+// sentinel returns the name of the sentinel generated for t.
+func (t errorType) sentinel() string {
+	return "err" + strings.ToUpper(t.name[:1]) + t.name[1:]
+}
+
+// errorTypes returns the exported, non-generic, non-interface types declared
+// in files that have an Error method.
+func errorTypes(files map[string]*ast.File) []errorType {
+	named := make(map[string]bool)
+	var methods []*ast.FuncDecl
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					ts := spec.(*ast.TypeSpec)
+					_, iface := ts.Type.(*ast.InterfaceType)
+					if ts.Name.IsExported() && !iface && ts.TypeParams == nil && !ts.Assign.IsValid() {
+						named[ts.Name.Name] = true
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv != nil && len(d.Recv.List) == 1 && d.Name.Name == "Error" &&
+					d.Type.Params.NumFields() == 0 && d.Type.Results.NumFields() == 1 {
+					methods = append(methods, d)
+				}
+			}
+		}
+	}
+	var out []errorType
+	for _, fd := range methods {
+		if res, ok := fd.Type.Results.List[0].Type.(*ast.Ident); !ok || res.Name != "string" {
+			continue
+		}
+		recv := fd.Recv.List[0].Type
+		star, pointer := recv.(*ast.StarExpr)
+		if pointer {
+			recv = star.X
+		}
+		if id, ok := recv.(*ast.Ident); ok && named[id.Name] {
+			out = append(out, errorType{id.Name, pointer})
+		}
+	}
+	return out
+}
+
+// code converts a sentinel name such as ErrNotFound into not_found.
+func code(name string) string {
+	for _, prefix := range []string{"Err", "err"} {
+		if len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			lowerNext := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (unicode.IsLower(runes[i-1]) || lowerNext) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
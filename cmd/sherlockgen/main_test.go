@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `package app
+
+import "errors"
+
+var ErrNotFound = errors.New("not found")
+
+type SyntaxError struct{ Line int }
+
+func (e SyntaxError) Error() string { return "syntax error" }
+
+type QuotaError struct{ Limit int }
+
+func (e *QuotaError) Error() string { return "quota exceeded" }
+
+type StatusError int
+
+type internalError struct{}
+
+func (e internalError) Error() string { return "internal" }
+
+type notAnError struct{}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.go"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	status := "package app\n\nfunc (e StatusError) Error() string { return \"status\" }\n"
+	if err := os.WriteFile(filepath.Join(dir, "status.go"), []byte(status), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generate(dir, "sherlock_gen.go", ""); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "sherlock_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		`sherlock.RegisterCodeMapping(ErrNotFound, "not_found")`,
+		`errSyntaxError = errors.New("syntax error")`,
+		`sherlock.RegisterCodeMapping(errSyntaxError, "syntax_error")`,
+		"var target SyntaxError\n",
+		`sherlock.RegisterCodeMapping(errQuotaError, "quota_error")`,
+		"var target *QuotaError\n",
+		"var target StatusError\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code lacks %q:\n%v", want, out)
+		}
+	}
+	for _, name := range []string{"notAnError", "internalError"} {
+		if strings.Contains(out, name) {
+			t.Errorf("generated code registers %v:\n%v", name, out)
+		}
+	}
+}
+
+const catalog = `{
+	"version": 2,
+	"errors": [
+		{"error": "not found", "code": "not_found", "http_status": 404, "public": "Not found", "severity": "info", "retryable": false},
+		{"error": "rate limited", "code": "rate_limited", "http_status": 429, "severity": "warning", "retryable": true},
+		{"error": "already declared", "code": "declared", "http_status": 500, "severity": "error"}
+	]
+}`
+
+func TestGenerateCatalog(t *testing.T) {
+	dir := t.TempDir()
+	src := "package app\n\nimport \"errors\"\n\nvar ErrDeclared = errors.New(\"already declared\")\n"
+	if err := os.WriteFile(filepath.Join(dir, "app.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "errors.json"), []byte(catalog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateCatalog(dir, "sherlock_gen.go", "", "errors.json"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "sherlock_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{
+		`ErrNotFound    = errors.New("not found")`,
+		`ErrRateLimited = errors.New("rate limited")`,
+		`sherlock.RegisterCodeMapping(ErrNotFound, "not_found")`,
+		`sherlock.RegisterHTTPStatus(ErrNotFound, 404)`,
+		`sherlock.RegisterPublicMessage(ErrNotFound, "Not found")`,
+		`sherlock.RegisterSeverity(ErrRateLimited, sherlock.SeverityWarning)`,
+		`sherlock.RegisterRetryable(ErrRateLimited)`,
+		`sherlock.RegisterCodeMapping(ErrDeclared, "declared")`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code lacks %q:\n%v", want, out)
+		}
+	}
+	if strings.Contains(out, "ErrDeclared =") {
+		t.Errorf("generated code declares ErrDeclared again:\n%v", out)
+	}
+}
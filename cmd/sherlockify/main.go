@@ -0,0 +1,293 @@
+/*
+Sherlockify rewrites simple error checks into sherlock.Check calls.
+
+	sherlockify [-w] file.go ...
+
+Within each function whose last result is a named error, statements of the form
+
+	if err != nil {
+		return err
+	}
+
+	if err := f(); err != nil {
+		return x, err
+	}
+
+are replaced by sherlock.Check(err) and sherlock.Check(f()) respectively, and a
+deferred sherlock.CatchAll of the named error is inserted at the top of the
+function. A check is only rewritten when the other values it returns are the
+function's own named results, so that the rewrite cannot change what the
+function returns. Checks containing comments are left alone. If the file
+already imports sherlock under another name, or with a dot, the calls are
+written to match, and a blank import is turned into a regular one.
+
+Without -w the rewritten source is printed to standard output.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+)
+
+const importPath = "github.com/alankm/sherlock"
+
+func main() {
+	write := flag.Bool("w", false, "write result to the source file instead of standard output")
+	flag.Parse()
+	status := 0
+	for _, path := range flag.Args() {
+		if err := rewriteFile(path, *write); err != nil {
+			fmt.Fprintf(os.Stderr, "sherlockify: %v\n", err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func rewriteFile(path string, write bool) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	out, changed, err := rewrite(path, src)
+	if err != nil {
+		return err
+	}
+	if !write {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// rewrite returns src, the contents of the file at path, with its checks
+// rewritten, and whether anything was changed.
+func rewrite(path string, src []byte) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	pkg := importName(f)
+	changed := false
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && rewriteFunc(f, fn, pkg) {
+			changed = true
+		}
+	}
+	if changed {
+		addImport(f)
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); err != nil {
+		return nil, false, err
+	}
+	return b.Bytes(), changed, nil
+}
+
+// importName returns the name f refers to sherlock by: the name it imports
+// sherlock under, "." for a dot import, or "sherlock" if it does not import it
+// or only imports it for its side effects.
+func importName(f *ast.File) string {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != importPath {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name != "_" {
+			return imp.Name.Name
+		}
+	}
+	return "sherlock"
+}
+
+// rewriteFunc rewrites the checks in the top level of fn's body, and reports
+// whether anything was changed.
+func rewriteFunc(f *ast.File, fn *ast.FuncDecl, pkg string) bool {
+	if fn.Body == nil || f.Name.Name == "sherlock" {
+		return false
+	}
+	results := resultNames(fn.Type.Results)
+	if results == nil {
+		return false
+	}
+	changed := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for i, stmt := range block.List {
+			ifs, ok := stmt.(*ast.IfStmt)
+			if !ok || hasComments(f, ifs) {
+				continue
+			}
+			if arg := checkArg(ifs, results); arg != nil {
+				block.List[i] = &ast.ExprStmt{X: call(pkg, "Check", arg, ifs.Pos(), ifs.End())}
+				changed = true
+			}
+		}
+		return true
+	})
+	if changed && !hasCatchAll(fn.Body, pkg) {
+		name := results[len(results)-1]
+		prologue := &ast.DeferStmt{
+			Call: call(pkg, "CatchAll", &ast.UnaryExpr{Op: token.AND, X: ast.NewIdent(name)}, token.NoPos, token.NoPos),
+		}
+		fn.Body.List = append([]ast.Stmt{prologue}, fn.Body.List...)
+	}
+	return changed
+}
+
+// resultNames returns the names of a function's results if they are named and
+// the last is of type error, or nil otherwise.
+func resultNames(fields *ast.FieldList) []string {
+	if fields == nil || len(fields.List) == 0 {
+		return nil
+	}
+	var names []string
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			return nil
+		}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	last := fields.List[len(fields.List)-1]
+	if id, ok := last.Type.(*ast.Ident); !ok || id.Name != "error" || names[len(names)-1] == "_" {
+		return nil
+	}
+	return names
+}
+
+// checkArg returns the expression to pass to sherlock.Check in place of ifs,
+// or nil if ifs is not a check that can be rewritten.
+func checkArg(ifs *ast.IfStmt, results []string) ast.Expr {
+	if ifs.Else != nil || len(ifs.Body.List) != 1 {
+		return nil
+	}
+	cond, ok := ifs.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ || !isIdent(cond.Y, "nil") {
+		return nil
+	}
+	errName, ok := cond.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	ret, ok := ifs.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != len(results) {
+		return nil
+	}
+	for i, res := range ret.Results[:len(ret.Results)-1] {
+		if !isIdent(res, results[i]) {
+			return nil
+		}
+	}
+	if !isIdent(ret.Results[len(ret.Results)-1], errName.Name) {
+		return nil
+	}
+	if ifs.Init == nil {
+		return errName
+	}
+	assign, ok := ifs.Init.(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 || !isIdent(assign.Lhs[0], errName.Name) {
+		return nil
+	}
+	return assign.Rhs[0]
+}
+
+func hasComments(f *ast.File, n ast.Node) bool {
+	for _, c := range f.Comments {
+		if c.Pos() >= n.Pos() && c.End() <= n.End() {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCatchAll(body *ast.BlockStmt, pkg string) bool {
+	for _, stmt := range body.List {
+		d, ok := stmt.(*ast.DeferStmt)
+		if !ok {
+			continue
+		}
+		switch fun := d.Call.Fun.(type) {
+		case *ast.SelectorExpr:
+			if isIdent(fun.X, pkg) && fun.Sel.Name == "CatchAll" {
+				return true
+			}
+		case *ast.Ident:
+			if pkg == "." && fun.Name == "CatchAll" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addImport imports sherlock into f unless it already does, turning a blank
+// import of it into a regular one.
+func addImport(f *ast.File) {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != importPath {
+			continue
+		}
+		if imp.Name != nil && imp.Name.Name == "_" {
+			imp.Name = nil
+		}
+		return
+	}
+	spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)}}
+	f.Imports = append(f.Imports, spec)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if !gen.Lparen.IsValid() {
+			gen.Lparen = gen.Pos()
+			gen.Rparen = gen.End()
+		}
+		gen.Specs = append(gen.Specs, spec)
+		return
+	}
+	gen := &ast.GenDecl{Tok: token.IMPORT, Specs: []ast.Spec{spec}}
+	f.Decls = append([]ast.Decl{gen}, f.Decls...)
+}
+
+// call builds a call to the named sherlock function, qualified by pkg unless
+// it is ".", spanning pos to end, so that the printer lays it out in place of
+// the statement it replaces.
+func call(pkg, name string, arg ast.Expr, pos, end token.Pos) *ast.CallExpr {
+	var fun ast.Expr = &ast.SelectorExpr{
+		X:   &ast.Ident{NamePos: pos, Name: pkg},
+		Sel: ast.NewIdent(name),
+	}
+	if pkg == "." {
+		fun = &ast.Ident{NamePos: pos, Name: name}
+	}
+	return &ast.CallExpr{
+		Fun:    fun,
+		Args:   []ast.Expr{arg},
+		Rparen: end,
+	}
+}
+
+func isIdent(e ast.Expr, name string) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == name
+}
@@ -0,0 +1,369 @@
+/*
+Sherlocklint reports misuse of sherlock's recovery functions that would go
+unnoticed at run time.
+
+	sherlocklint [dir ...]
+
+The following are reported:
+
+  - Catch, CatchAll, CatchAtLeast or CatchAtMost called without defer, which
+    never recovers anything.
+  - CatchAll given a nil pointer, or a pointer to a local variable that is not
+    one of the function's named results, so that the caught error is lost when
+    the function returns.
+  - A goroutine started with a function literal that throws without a deferred
+    Catch of its own, which crashes the process rather than returning the
+    error to the goroutine's parent.
+  - A function that throws where no deferred Catch is reachable: neither the
+    function nor every chain of callers leading to it within the package
+    defers one. Exported functions and methods, main, init and functions used
+    other than by calling them are entry points, whose callers are unknown, so
+    they must catch for themselves. Calls are followed by name, so a call to a
+    method is taken to reach every method of that name in the package. A
+    function that is meant to throw to its callers, or that recovers by some
+    other means, can be exempted with a //sherlocklint:ignore line in its doc
+    comment, which also covers the functions it calls.
+
+Test files are ignored. The exit status is 1 if anything was reported.
+*/
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const importPath = "github.com/alankm/sherlock"
+
+var (
+	catches = map[string]bool{"Catch": true, "CatchAll": true, "CatchAtLeast": true, "CatchAtMost": true}
+	throws  = map[string]bool{"Assert": true, "Check": true, "CheckCtx": true, "CheckIn": true, "Throw": true}
+)
+
+type problem struct {
+	pos token.Position
+	msg string
+}
+
+func main() {
+	dirs := os.Args[1:]
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	found := false
+	for _, dir := range dirs {
+		problems, err := lint(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sherlocklint: %v\n", err)
+			os.Exit(2)
+		}
+		for _, p := range problems {
+			fmt.Printf("%v: %v\n", p.pos, p.msg)
+			found = true
+		}
+	}
+	if found {
+		os.Exit(1)
+	}
+}
+
+func lint(dir string) ([]problem, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var problems []problem
+	for _, pkg := range pkgs {
+		g := &graph{funcs: make(map[string][]*node)}
+		for _, f := range pkg.Files {
+			l := &linter{fset: fset, name: importName(f)}
+			for _, decl := range f.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+					g.add(l, pkg.Name, fn)
+					if l.name != "" {
+						l.function(fn.Type, fn.Body)
+					}
+				}
+			}
+			problems = append(problems, l.problems...)
+		}
+		problems = append(problems, g.uncaught(fset)...)
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		a, b := problems[i].pos, problems[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Line < b.Line
+	})
+	return problems, nil
+}
+
+// importName returns the name that f imports sherlock under, or the empty
+// string if it does not import it.
+func importName(f *ast.File) string {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != importPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return "sherlock"
+	}
+	return ""
+}
+
+type linter struct {
+	fset     *token.FileSet
+	name     string
+	problems []problem
+}
+
+func (l *linter) report(pos token.Pos, format string, args ...interface{}) {
+	l.problems = append(l.problems, problem{l.fset.Position(pos), fmt.Sprintf(format, args...)})
+}
+
+// sherlockCall returns the name of the sherlock function called by n, if any.
+func (l *linter) sherlockCall(n ast.Node) (string, *ast.CallExpr) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return "", nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", nil
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != l.name {
+		return "", nil
+	}
+	return sel.Sel.Name, call
+}
+
+// function checks the body of a single function, descending into function
+// literals as functions of their own.
+func (l *linter) function(typ *ast.FuncType, body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			l.function(n.Type, n.Body)
+			return false
+		case *ast.ExprStmt:
+			if name, call := l.sherlockCall(n.X); catches[name] {
+				l.report(call.Pos(), "%v.%v must be deferred to recover anything", l.name, name)
+			}
+		case *ast.DeferStmt:
+			if name, call := l.sherlockCall(n.Call); name == "CatchAll" && len(call.Args) == 1 {
+				l.target(typ, body, call.Args[0])
+			}
+		case *ast.GoStmt:
+			if lit, ok := n.Call.Fun.(*ast.FuncLit); ok && l.throws(lit.Body) && !l.catches(lit.Body) {
+				l.report(n.Pos(), "goroutine throws without a deferred Catch of its own")
+			}
+		}
+		return true
+	})
+}
+
+// target checks the argument to a deferred CatchAll.
+func (l *linter) target(typ *ast.FuncType, body *ast.BlockStmt, arg ast.Expr) {
+	if id, ok := arg.(*ast.Ident); ok && id.Name == "nil" {
+		l.report(arg.Pos(), "%v.CatchAll given a nil pointer", l.name)
+		return
+	}
+	u, ok := arg.(*ast.UnaryExpr)
+	if !ok || u.Op != token.AND {
+		return
+	}
+	id, ok := u.X.(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return
+	}
+	decl, ok := id.Obj.Decl.(ast.Node)
+	if !ok || decl.Pos() < body.Pos() || decl.End() > body.End() {
+		// Named results and variables captured from an enclosing function
+		// outlive the deferred call.
+		return
+	}
+	l.report(arg.Pos(), "%v.CatchAll fills %v, which is not a named result, so the error is lost", l.name, id.Name)
+}
+
+// throws reports whether body calls one of sherlock's throwing functions,
+// outside of any function literal.
+func (l *linter) throws(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if name, _ := l.sherlockCall(n); throws[name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// catches reports whether body defers one of sherlock's recovery functions at
+// its top level.
+func (l *linter) catches(body *ast.BlockStmt) bool {
+	for _, stmt := range body.List {
+		if d, ok := stmt.(*ast.DeferStmt); ok {
+			if name, _ := l.sherlockCall(d.Call); catches[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// node is a function or method declared in the package being linted.
+type node struct {
+	name    string
+	entry   bool
+	catches bool
+	throw   *ast.CallExpr // the first call that throws, if any
+	thrower string        // the qualified name of the function it calls
+	callers []*node
+
+	state int // for covered: 0 unvisited, 1 in progress, 2 done
+	cover bool
+}
+
+// graph is the call graph of a package, by function name.
+type graph struct {
+	funcs map[string][]*node
+	calls []call
+	uses  []string // functions referred to other than by calling them
+}
+
+type call struct {
+	from *node
+	to   string
+}
+
+// add records fn, declared in the package named pkg, with the calls it makes.
+func (g *graph) add(l *linter, pkg string, fn *ast.FuncDecl) {
+	n := &node{name: fn.Name.Name}
+	n.entry = fn.Name.IsExported() || fn.Recv == nil && (fn.Name.Name == "init" || pkg == "main" && fn.Name.Name == "main")
+	if l.name != "" {
+		n.catches = l.catches(fn.Body) || ignored(fn.Doc)
+	}
+	g.funcs[n.name] = append(g.funcs[n.name], n)
+	called := make(map[ast.Expr]bool)
+	ast.Inspect(fn.Body, func(x ast.Node) bool {
+		switch x := x.(type) {
+		case *ast.GoStmt:
+			// Goroutines are checked for catches of their own.
+			if _, ok := x.Call.Fun.(*ast.FuncLit); ok {
+				return false
+			}
+		case *ast.FuncLit:
+			// A literal that catches for itself covers what it throws.
+			return !called[x] && (l.name == "" || !l.catches(x.Body))
+		case *ast.CallExpr:
+			if name, _ := l.sherlockCall(x); name != "" {
+				if throws[name] && n.throw == nil {
+					n.throw, n.thrower = x, l.name+"."+name
+				}
+				sel := x.Fun.(*ast.SelectorExpr)
+				called[sel.X], called[sel.Sel] = true, true
+				// Function literals passed to sherlock, such as to Barrier,
+				// are run under its own recovery.
+				for _, arg := range x.Args {
+					if _, ok := arg.(*ast.FuncLit); ok {
+						called[arg] = true
+					}
+				}
+				return true
+			}
+			switch fun := x.Fun.(type) {
+			case *ast.Ident:
+				called[fun] = true
+				g.calls = append(g.calls, call{n, fun.Name})
+			case *ast.SelectorExpr:
+				called[fun.Sel] = true
+				g.calls = append(g.calls, call{n, fun.Sel.Name})
+			}
+		case *ast.Ident:
+			if !called[x] {
+				g.uses = append(g.uses, x.Name)
+			}
+		}
+		return true
+	})
+}
+
+// ignored reports whether doc exempts its function from the reachability check.
+func ignored(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if c.Text == "//sherlocklint:ignore" {
+			return true
+		}
+	}
+	return false
+}
+
+// uncaught reports the functions that throw with no deferred Catch reachable.
+func (g *graph) uncaught(fset *token.FileSet) []problem {
+	for _, c := range g.calls {
+		for _, to := range g.funcs[c.to] {
+			to.callers = append(to.callers, c.from)
+		}
+	}
+	for _, name := range g.uses {
+		for _, n := range g.funcs[name] {
+			n.entry = true
+		}
+	}
+	var problems []problem
+	for _, nodes := range g.funcs {
+		for _, n := range nodes {
+			if n.throw != nil && !n.covered() {
+				problems = append(problems, problem{
+					fset.Position(n.throw.Pos()),
+					fmt.Sprintf("%v calls %v without a deferred Catch in it or in all of its callers", n.name, n.thrower),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// covered reports whether every chain of calls reaching n passes through a
+// function that defers a Catch. Functions with no callers are taken to be
+// unused, and so covered, unless they are entry points.
+func (n *node) covered() bool {
+	switch n.state {
+	case 1:
+		// A cycle adds no chain from an entry point of its own.
+		return true
+	case 2:
+		return n.cover
+	}
+	n.state = 1
+	n.cover = n.catches
+	if !n.cover && !n.entry {
+		n.cover = true
+		for _, c := range n.callers {
+			if !c.covered() {
+				n.cover = false
+				break
+			}
+		}
+	}
+	n.state = 2
+	return n.cover
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var want = regexp.MustCompile(`// want "(.*)"$`)
+
+// expected returns the diagnostics the files in dir are marked with, by a
+// trailing // want "message" comment on the line they are expected on.
+func expected(t *testing.T, dir string) map[string]bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]bool)
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := bufio.NewScanner(f)
+		for line := 1; sc.Scan(); line++ {
+			if m := want.FindStringSubmatch(sc.Text()); m != nil {
+				out[fmt.Sprintf("%v:%v: %v", name, line, m[1])] = true
+			}
+		}
+		f.Close()
+	}
+	return out
+}
+
+func TestLint(t *testing.T) {
+	for _, dir := range []string{"misuse", "clean", "reach"} {
+		dir := filepath.Join("testdata", dir)
+		problems, err := lint(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := expected(t, dir)
+		for _, p := range problems {
+			got := fmt.Sprintf("%v:%v: %v", p.pos.Filename, p.pos.Line, p.msg)
+			if !want[got] {
+				t.Errorf("unexpected %v", got)
+			}
+			delete(want, got)
+		}
+		for missing := range want {
+			t.Errorf("missing %v", missing)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package clean
+
+import (
+	"errors"
+
+	sh "github.com/alankm/sherlock"
+)
+
+var errDone = errors.New("done")
+
+func named() (err error) {
+	defer sh.CatchAll(&err)
+	sh.Check(err)
+	return nil
+}
+
+func captured() (err error) {
+	func() {
+		defer sh.CatchAll(&err)
+		sh.Throw(err)
+	}()
+	return err
+}
+
+func goroutine(errs chan error) {
+	go func() {
+		defer sh.Catch(errDone, func() {})
+		sh.Throw(<-errs)
+	}()
+	go func() {
+		errs <- nil
+	}()
+}
@@ -0,0 +1,28 @@
+package misuse
+
+import "github.com/alankm/sherlock"
+
+func notDeferred() (err error) {
+	sherlock.CatchAll(&err) // want "sherlock.CatchAll must be deferred to recover anything"
+	sherlock.Check(err)
+	return nil
+}
+
+func nilTarget() error {
+	defer sherlock.CatchAll(nil) // want "sherlock.CatchAll given a nil pointer"
+	sherlock.Throw(nil)
+	return nil
+}
+
+func localTarget() error {
+	var err error
+	defer sherlock.CatchAll(&err) // want "sherlock.CatchAll fills err, which is not a named result, so the error is lost"
+	sherlock.Throw(err)
+	return err
+}
+
+func goroutine(errs chan error) {
+	go func() { // want "goroutine throws without a deferred Catch of its own"
+		sherlock.Throw(<-errs)
+	}()
+}
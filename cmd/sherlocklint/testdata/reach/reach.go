@@ -0,0 +1,75 @@
+package reach
+
+import (
+	"net/http"
+
+	"github.com/alankm/sherlock"
+)
+
+// Exported throws to callers outside the package, which cannot be known to
+// catch.
+func Exported(err error) {
+	sherlock.Throw(err) // want "Exported calls sherlock.Throw without a deferred Catch in it or in all of its callers"
+}
+
+// Caught covers helper, which it calls.
+func Caught() (err error) {
+	defer sherlock.CatchAll(&err)
+	helper(nil)
+	return nil
+}
+
+func helper(err error) {
+	sherlock.Check(err)
+}
+
+// Leaky reaches shared without catching, so shared is reported even though
+// Caught2 catches when it calls it.
+func Leaky() {
+	shared(nil)
+}
+
+func Caught2() (err error) {
+	defer sherlock.CatchAll(&err)
+	shared(nil)
+	return nil
+}
+
+func shared(err error) {
+	sherlock.Check(err) // want "shared calls sherlock.Check without a deferred Catch in it or in all of its callers"
+}
+
+// handler escapes as a value, so it is an entry point.
+func Serve() {
+	http.HandleFunc("/", handler)
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	sherlock.Assert(r != nil, nil) // want "handler calls sherlock.Assert without a deferred Catch in it or in all of its callers"
+}
+
+// Barriered throws only inside sherlock's own recovery.
+func Barriered() {
+	sherlock.Barrier(func() {
+		sherlock.Throw(nil)
+	})
+}
+
+func unused() {
+	sherlock.Throw(nil)
+}
+
+func init() {
+	sherlock.Throw(nil) // want "init calls sherlock.Throw without a deferred Catch in it or in all of its callers"
+}
+
+// fail is meant to throw to its callers.
+//
+//sherlocklint:ignore
+func fail(err error) {
+	sherlock.Throw(err)
+}
+
+func Fails() {
+	fail(nil)
+}
@@ -0,0 +1,48 @@
+package sherlock
+
+// Coded is an error carrying a stable, machine-readable code that clients can
+// depend on independently of the message text. Message is the internal message
+// and Public, if set, the message that is safe to show to users. HTTPStatus,
+// if set, is the status code to respond with.
+type Coded struct {
+	Code       string
+	Message    string
+	Public     string
+	HTTPStatus int
+	Err        error
+}
+
+func (c *Coded) Error() string {
+	if c.Message == "" && c.Err != nil {
+		return c.Err.Error()
+	}
+	return c.Message
+}
+
+func (c *Coded) Unwrap() error {
+	return c.Err
+}
+
+var codes table[string]
+
+// RegisterCodeMapping registers code as the stable code of err, so that Code
+// reports it for err wherever err is caught.
+func RegisterCodeMapping(err error, code string) {
+	codes.set(err, code)
+}
+
+// Code returns the code of err. A Coded error in err's chain takes precedence
+// over any registered mapping. If err has no code, the empty string is
+// returned.
+func Code(err error) string {
+	if c := coded(err); c != nil {
+		return c.Code
+	}
+	code, _ := codes.lookup(err)
+	return code
+}
+
+// byCode returns the first error registered with code.
+func byCode(code string) (error, bool) {
+	return codes.key(func(c string) bool { return c == code })
+}
@@ -0,0 +1,50 @@
+//go:build js
+
+package sherlock
+
+import "syscall/js"
+
+// defaultBackend returns the backend diagnostics are written to unless
+// SetBackend is used. Under js this is the console, since standard error is
+// not always visible there.
+func defaultBackend() Backend {
+	return ConsoleBackend()
+}
+
+type consoleBackend struct{}
+
+// ConsoleBackend returns a Backend that writes diagnostics to the JavaScript
+// console, using console.error for bugs, console.warn for warnings and
+// console.info for caught errors. It is the default backend under GOOS=js.
+func ConsoleBackend() Backend {
+	return consoleBackend{}
+}
+
+func (consoleBackend) Emit(e Entry) error {
+	msg := e.Message
+	if e.Hint != "" {
+		msg += "\nhint: " + e.Hint
+	}
+	if e.CorrelationID != "" {
+		msg += "\ncorrelation id: " + e.CorrelationID
+	}
+	if e.Source != "" {
+		msg += "\n" + e.Source
+	}
+	if e.Stack != "" {
+		msg += "\n" + e.Stack
+	}
+	console := js.Global().Get("console")
+	if console.IsUndefined() {
+		return nil
+	}
+	method := "error"
+	switch {
+	case !e.Severity.atLeast(SeverityWarning):
+		method = "info"
+	case !e.Severity.atLeast(SeverityError):
+		method = "warn"
+	}
+	console.Call(method, msg)
+	return nil
+}
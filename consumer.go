@@ -0,0 +1,96 @@
+package sherlock
+
+import (
+	"time"
+)
+
+// Decision is what a message consumer should do with a message once it has
+// been processed.
+type Decision int
+
+const (
+	// Ack acknowledges the message as processed.
+	Ack Decision = iota
+	// Nack rejects the message without redelivery.
+	Nack
+	// Requeue rejects the message for redelivery.
+	Requeue
+)
+
+// Consumer wraps a message handler with a recovery boundary for each message,
+// so that handlers can use Check and Throw freely without killing the consumer
+// loop. It is independent of any particular broker: call Process for each
+// message received and act on the returned Decision.
+type Consumer[M any] struct {
+	// Handle processes a single message.
+	Handle func(msg M) error
+	// Policy decides what to do with a message given the error its processing
+	// ended with, which is nil on success. If Policy is nil, DefaultPolicy is
+	// used.
+	Policy func(msg M, err error) Decision
+	// DeadLetter, if set, is called for messages whose processing ends in a
+	// panic that was not raised by sherlock. Such messages are rejected rather
+	// than the panic being rethrown.
+	DeadLetter func(msg M, letter Letter)
+}
+
+// Letter is the diagnostic bundle handed to a dead-letter publisher.
+type Letter struct {
+	Time        time.Time
+	Err         *PanicError
+	Fingerprint string
+}
+
+// Headers returns the bundle as message headers, with the stack redacted.
+func (l Letter) Headers() map[string]string {
+	return map[string]string{
+		"sherlock-time":        timestamp(l.Time).Format(time.RFC3339Nano),
+		"sherlock-error":       redact(l.Err.Error()),
+		"sherlock-fingerprint": l.Fingerprint,
+		"sherlock-stack":       redact(normalizeStack(l.Err.Stack)),
+	}
+}
+
+// DefaultPolicy acknowledges messages that were processed successfully,
+// requeues those that failed with a retryable error, and rejects the rest.
+func DefaultPolicy(err error) Decision {
+	switch {
+	case err == nil:
+		return Ack
+	case IsRetryable(err):
+		return Requeue
+	}
+	return Nack
+}
+
+// Process handles msg within a CatchAll boundary and returns the decision for
+// it.
+func (c *Consumer[M]) Process(msg M) Decision {
+	letter, err := c.process(msg)
+	if letter != nil {
+		c.DeadLetter(msg, *letter)
+		return Nack
+	}
+	if c.Policy != nil {
+		return c.Policy(msg, err)
+	}
+	return DefaultPolicy(err)
+}
+
+func (c *Consumer[M]) process(msg M) (letter *Letter, err error) {
+	defer func() {
+		r := recover()
+		if _, ok := r.(*report); r == nil || ok || c.DeadLetter == nil {
+			catch(r, &err)
+			return
+		}
+		stack := bug(r)
+		letter = &Letter{
+			Time:        time.Now(),
+			Err:         &PanicError{Value: r, Stack: stack},
+			Fingerprint: panicFingerprint(r, stack),
+		}
+		record(r, letter.Err, ActionUnexpected)
+	}()
+	return nil, c.Handle(msg)
+}
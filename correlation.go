@@ -0,0 +1,43 @@
+package sherlock
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// correlationKey holds the context key set with SetCorrelationKey, boxed so
+// that a nil key can be stored.
+var correlationKey atomic.Value // correlationBox
+
+type correlationBox struct {
+	key interface{}
+}
+
+// SetCorrelationKey sets the context key that requests store their correlation
+// ID under, such as a request ID set by earlier middleware. Errors thrown by
+// CheckIn, CheckCtx and Handle.CheckCtx, and errors caught by Recoverer, then
+// carry the value of ctx.Value(key), formatted with fmt.Sprint, as their
+// correlation ID. The ID is included in every diagnostic written about the
+// error and in its audit events, and so in the events delivered by Subscribe,
+// so that the error events of one request can be stitched together. A nil key
+// disables correlation IDs, which is the default.
+func SetCorrelationKey(key interface{}) {
+	correlationKey.Store(correlationBox{key})
+}
+
+// correlationID returns the correlation ID stored in ctx, or "" if there is
+// none or no key has been set.
+func correlationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	box, _ := correlationKey.Load().(correlationBox)
+	if box.key == nil {
+		return ""
+	}
+	if v := ctx.Value(box.key); v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+type requestIDKey struct{}
+
+func TestCorrelationID(t *testing.T) {
+	r := record(t)
+	sherlock.SetCorrelationKey(requestIDKey{})
+	defer sherlock.SetCorrelationKey(nil)
+	sherlock.SetAuditSize(10)
+	defer sherlock.SetAuditSize(0)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	func() {
+		defer sherlock.CatchAll(new(error))
+		sherlock.CheckIn(ctx, errors.New("checked in"))
+	}()
+	h := sherlock.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sherlock.Check(errors.New("served"))
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(context.WithValue(req.Context(), requestIDKey{}, "req-2")))
+
+	want := []string{"req-1", "req-2"}
+	if len(r.entries) != len(want) {
+		t.Fatalf("got %d entries", len(r.entries))
+	}
+	for i, e := range r.entries {
+		if e.CorrelationID != want[i] {
+			t.Errorf("entry %d has correlation ID %q, want %q", i, e.CorrelationID, want[i])
+		}
+	}
+	log := sherlock.AuditLog()
+	if len(log) != len(want) {
+		t.Fatalf("got %d audit events", len(log))
+	}
+	for i, e := range log {
+		if e.CorrelationID != want[i] {
+			t.Errorf("audit event %d has correlation ID %q, want %q", i, e.CorrelationID, want[i])
+		}
+	}
+}
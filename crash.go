@@ -0,0 +1,93 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var crash struct {
+	sync.Mutex
+	dir string
+}
+
+// SetCrashDir enables crash reports. Whenever sherlock encounters a panic that
+// it considers to be a bug it writes a self-contained report file into dir,
+// containing the error, both the throwing and catching stacks, the platform,
+// every registered error with how it maps, the most recent events of the audit
+// trail if SetAuditSize enabled it, and the build information of the binary.
+// An empty dir disables crash reports, which is the default.
+func SetCrashDir(dir string) {
+	crash.Lock()
+	crash.dir = dir
+	crash.Unlock()
+}
+
+// crashAuditTail is the number of the most recent audit events a crash report
+// includes.
+const crashAuditTail = 50
+
+func writeCrashReport(r interface{}, stack string) {
+	crash.Lock()
+	dir := crash.dir
+	crash.Unlock()
+	if dir == "" {
+		return
+	}
+	now := time.Now()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "time: %v\n", timestamp(now).Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "runtime: %v %v/%v\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "fingerprint: %v\n", panicFingerprint(r, stack))
+	if x, ok := r.(*report); ok {
+		fmt.Fprintf(&b, "package: %v\n", x.pkg)
+		fmt.Fprintf(&b, "error: %v\n", x.err)
+		if hint := Hint(x.err); hint != "" {
+			fmt.Fprintf(&b, "hint: %v\n", hint)
+		}
+		fmt.Fprintf(&b, "\nthrown at:\n%v\n", normalizeStack(x.stack()))
+	} else {
+		fmt.Fprintf(&b, "panic: %v\n", r)
+	}
+	fmt.Fprintf(&b, "\ncaught at:\n%v\n", normalizeStack(stack))
+	if events := AuditLog(); len(events) > 0 {
+		if len(events) > crashAuditTail {
+			events = events[len(events)-crashAuditTail:]
+		}
+		fmt.Fprintf(&b, "\nrecent errors:\n")
+		for _, e := range events {
+			fmt.Fprintf(&b, "%v %v %v: %v\n", e.Time.Format(time.RFC3339Nano), e.Action, e.Package, e.Err)
+		}
+	}
+	if errs := registeredErrors(); len(errs) > 0 {
+		counted := atomic.LoadInt32(&countHits) != 0
+		fmt.Fprintf(&b, "\nregistered errors:\n")
+		for _, err := range errs {
+			c := catalogEntry(err)
+			fmt.Fprintf(&b, "%v: code %q, status %v, severity %v", c.Error, c.Code, c.HTTPStatus, c.Severity)
+			if counted {
+				fmt.Fprintf(&b, ", %v hits", hits(err))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "\nbuild info:\n%v\n", info)
+	}
+	name := fmt.Sprintf("sherlock-%v-%v.txt", now.UTC().Format("20060102T150405.000000000"), os.Getpid())
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, name), []byte(redact(b.String())), 0644)
+	}
+	if err != nil {
+		emit(Entry{Severity: SeverityError, Message: fmt.Sprintf("sherlock: could not write crash report: %v", err)})
+	}
+}
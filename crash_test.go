@@ -0,0 +1,47 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestCrashReport(t *testing.T) {
+	record(t)
+	dir := t.TempDir()
+	sherlock.SetCrashDir(dir)
+	defer sherlock.SetCrashDir("")
+	sherlock.SetAuditSize(10)
+	defer sherlock.SetAuditSize(0)
+	errQuota := errors.New("crash report: quota exceeded")
+	sherlock.RegisterCodeMapping(errQuota, "crash_quota")
+	sherlock.Ok(errQuota)
+	sherlock.Barrier(func() { panic("crash report: boom") })
+
+	files, err := filepath.Glob(filepath.Join(dir, "sherlock-*.txt"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("found crash reports %v, %v", files, err)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(b)
+	for _, want := range []string{
+		"panic: crash report: boom",
+		"\nrecent errors:\n",
+		"observed",
+		"\nregistered errors:\n",
+		`crash report: quota exceeded: code "crash_quota"`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("crash report lacks %q:\n%v", want, report)
+		}
+	}
+}
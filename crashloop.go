@@ -0,0 +1,84 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var crashLoop struct {
+	sync.Mutex
+	n        int
+	window   time.Duration
+	cooldown time.Duration
+}
+
+// SetCrashLoop makes Main detect crash loops: a process ending with the same
+// unexpected panic n times within window, as counted by fingerprint from the
+// reports in the crash directory. When one is detected a distinct fatal
+// diagnostic is written, and Main waits for cooldown before exiting, so that
+// whatever restarts the process does not restart it hot forever. Detection
+// requires SetCrashDir. A zero n disables detection, which is the default.
+// Supervisor has equivalent fields of its own.
+func SetCrashLoop(n int, window, cooldown time.Duration) {
+	crashLoop.Lock()
+	crashLoop.n = n
+	crashLoop.window = window
+	crashLoop.cooldown = cooldown
+	crashLoop.Unlock()
+}
+
+// coolDown waits out the crash loop cooldown if the panic with the given
+// fingerprint has ended the process too often recently.
+func coolDown(fingerprint string) {
+	crashLoop.Lock()
+	n, window, cooldown := crashLoop.n, crashLoop.window, crashLoop.cooldown
+	crashLoop.Unlock()
+	crash.Lock()
+	dir := crash.dir
+	crash.Unlock()
+	if n <= 0 || dir == "" {
+		return
+	}
+	if seen := crashes(dir, fingerprint, time.Now().Add(-window)); seen >= n {
+		crashLooping(fingerprint, seen, cooldown)
+		time.Sleep(cooldown)
+	}
+}
+
+// crashes counts the crash reports in dir written since the given time with
+// the given fingerprint.
+func crashes(dir, fingerprint string, since time.Time) int {
+	matches, _ := filepath.Glob(filepath.Join(dir, "sherlock-*.txt"))
+	n := 0
+	for _, path := range matches {
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Before(since) {
+			continue
+		}
+		if reportFingerprint(path) == fingerprint {
+			n++
+		}
+	}
+	return n
+}
+
+func reportFingerprint(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if fp, ok := strings.CutPrefix(sc.Text(), "fingerprint: "); ok {
+			return fp
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+type debugEvent struct {
+	Time    time.Time
+	Package string
+	Error   string
+}
+
+type debugRule struct {
+	CatalogEntry
+	Hits uint64
+}
+
+type debugPage struct {
+	Rules      []debugRule
+	Stats      Counters
+	Total      Counters
+	Top        []ErrorCount
+	Unexpected []debugEvent
+	Message    string
+	Classified *CatalogEntry
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>sherlock</title></head>
+<body>
+<h1>sherlock</h1>
+<h2>Classify</h2>
+<form method="get"><input name="message" size="80" value="{{.Message}}"> <input type="submit" value="Classify"></form>
+{{with .Classified}}<table border="1">
+<tr><th>Code</th><th>Status</th><th>Public</th><th>Hint</th><th>Severity</th><th>Retryable</th></tr>
+<tr><td>{{.Code}}</td><td>{{.HTTPStatus}}</td><td>{{.Public}}</td><td>{{.Hint}}</td><td>{{.Severity}}</td><td>{{.Retryable}}</td></tr>
+</table>
+{{end}}<h2>Counters</h2>
+<p>caught {{.Stats.Caught}}, unexpected {{.Stats.Unexpected}}, dropped {{.Stats.Dropped}}, near misses {{.Stats.NearMisses}}</p>
+<p>including previous runs: caught {{.Total.Caught}}, unexpected {{.Total.Unexpected}}, dropped {{.Total.Dropped}}, near misses {{.Total.NearMisses}}</p>
+<h2>Most thrown in the last hour</h2>
+<table border="1">
+<tr><th>Error</th><th>Count</th></tr>
+{{range .Top}}<tr><td>{{.Message}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Recent unexpected errors</h2>
+<table border="1">
+<tr><th>Time</th><th>Package</th><th>Error</th></tr>
+{{range .Unexpected}}<tr><td>{{.Time}}</td><td>{{.Package}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+<h2>Registered errors</h2>
+<table border="1">
+<tr><th>Error</th><th>Code</th><th>Status</th><th>Public</th><th>Hint</th><th>Severity</th><th>Retryable</th><th>Hits</th></tr>
+{{range .Rules}}<tr><td>{{.Error}}</td><td>{{.Code}}</td><td>{{.HTTPStatus}}</td><td>{{.Public}}</td><td>{{.Hint}}</td><td>{{.Severity}}</td><td>{{.Retryable}}</td><td>{{.Hits}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// DebugHandler returns a handler serving a page that lists every registered
+// error with what is registered for it and how many thrown errors matched it,
+// the counters, the most frequently thrown errors, and the recent unexpected
+// errors recorded in the audit trail. A form classifies a given error message
+// as if it were thrown. It is intended to be mounted under /debug/sherlock, and
+// like pprof must not be exposed publicly.
+//
+// DebugHandler enables SetRuleHits, so hits are counted from when it is first
+// called.
+func DebugHandler() http.Handler {
+	SetRuleHits(true)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := debugPage{
+			Stats:   Stats(),
+			Total:   TotalStats(),
+			Top:     TopErrors(20, time.Hour),
+			Message: r.FormValue("message"),
+		}
+		for _, h := range RuleHits() {
+			page.Rules = append(page.Rules, debugRule{catalogEntry(h.Err), h.Hits})
+		}
+		for i := range page.Top {
+			page.Top[i].Message = redact(page.Top[i].Message)
+		}
+		for _, e := range AuditLog() {
+			if e.Action == ActionUnexpected {
+				page.Unexpected = append(page.Unexpected, debugEvent{
+					Time:    timestamp(e.Time),
+					Package: e.Package,
+					Error:   redact(fmt.Sprint(e.Err)),
+				})
+			}
+		}
+		if page.Message != "" {
+			rule := catalogEntry(classifyMessage(page.Message))
+			page.Classified = &rule
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := debugTemplate.Execute(w, page); err != nil {
+			emit(Entry{Severity: SeverityError, Message: "sherlock: could not render debug page: " + err.Error()})
+		}
+	})
+}
+
+// classifyMessage returns the error a thrown error with the given message
+// would be treated as: a registered error with the same message if there is
+// one, or the result of the registered classifiers otherwise.
+func classifyMessage(msg string) error {
+	for _, err := range registeredErrors() {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return classify(errors.New(msg))
+}
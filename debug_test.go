@@ -0,0 +1,38 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestDebugHandlerHits(t *testing.T) {
+	sherlock.SetOutput(io.Discard)
+	defer sherlock.SetBackend(nil)
+	errHit := errors.New("debug hit sentinel")
+	sherlock.RegisterCodeMapping(errHit, "debug_hit")
+	h := sherlock.DebugHandler()
+	for i := 0; i < 3; i++ {
+		func() {
+			defer sherlock.CatchAll(new(error))
+			sherlock.Throw(fmt.Errorf("wrapped: %w", errHit))
+		}()
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/sherlock", nil))
+	if row := "<td>debug_hit</td>"; !strings.Contains(w.Body.String(), row) {
+		t.Fatalf("page lacks %q", row)
+	}
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.Contains(line, "<td>debug_hit</td>") && !strings.HasSuffix(line, "<td>3</td></tr>") {
+			t.Fatalf("got row %q, want 3 hits", line)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package sherlock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var deterministic int32
+
+// SetDeterministic enables or disables deterministic diagnostics. When
+// enabled, stacks are stripped of goroutine IDs, argument values, program
+// counter offsets and directories, and timestamps are zeroed, so that
+// diagnostics can be compared against golden files across machines and runs.
+func SetDeterministic(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&deterministic, v)
+}
+
+func isDeterministic() bool {
+	return atomic.LoadInt32(&deterministic) == 1
+}
+
+// normalizeStack rewrites a stack for deterministic output, if enabled.
+func normalizeStack(s string) string {
+	if !isDeterministic() {
+		return s
+	}
+	return scrubStack(s)
+}
+
+// timestamp returns t, or the zero time in deterministic mode.
+func timestamp(t time.Time) time.Time {
+	if isDeterministic() {
+		return time.Time{}
+	}
+	return t
+}
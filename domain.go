@@ -0,0 +1,106 @@
+package sherlock
+
+import (
+	"context"
+	"sync"
+)
+
+var domains struct {
+	sync.Mutex
+	m map[string]*Overlay
+}
+
+// Domain returns the overlay of the named error domain, creating it on first
+// use. Domain names are paths separated by dots, such as "storage.s3", whose
+// parent is "storage". Registrations and classifiers added to a domain apply
+// to errors checked with CheckIn or CheckCtx under a context returned by
+// InDomain for it or any of its children.
+func Domain(name string) *Overlay {
+	domains.Lock()
+	defer domains.Unlock()
+	if o, ok := domains.m[name]; ok {
+		return o
+	}
+	if domains.m == nil {
+		domains.m = make(map[string]*Overlay)
+	}
+	o := &Overlay{domain: name}
+	domains.m[name] = o
+	return o
+}
+
+// InDomain returns a copy of ctx with the overlays of the named domain and of
+// each of its parents attached, the named domain innermost. A child domain
+// therefore inherits the registrations and classifiers of its parents, and
+// shadows any it registers for itself.
+func InDomain(ctx context.Context, name string) context.Context {
+	for i := 0; i <= len(name); i++ {
+		if i == len(name) || name[i] == '.' {
+			ctx = WithOverlay(ctx, Domain(name[:i]))
+		}
+	}
+	return ctx
+}
+
+// DomainOf returns the name of the domain whose classifier classified err, or
+// "" if err was not classified by a domain, so that code catching err can tell
+// which part of the program recognised it.
+func DomainOf(err error) string {
+	var domain string
+	walk(err, func(e error) bool {
+		if c, ok := e.(*classified); ok && c.domain != "" {
+			domain = c.domain
+			return true
+		}
+		return false
+	})
+	return domain
+}
+
+// domainState is a copy of a domain's overlay, taken by Snapshot.
+type domainState struct {
+	name        string
+	o           *Overlay
+	codes       tableState[string]
+	public      tableState[string]
+	statuses    tableState[int]
+	classifiers []Classifier
+}
+
+func snapshotDomains() []domainState {
+	domains.Lock()
+	defer domains.Unlock()
+	s := make([]domainState, 0, len(domains.m))
+	for name, o := range domains.m {
+		o.mu.RLock()
+		s = append(s, domainState{
+			name:        name,
+			o:           o,
+			codes:       o.codes.snapshot(),
+			public:      o.public.snapshot(),
+			statuses:    o.statuses.snapshot(),
+			classifiers: append([]Classifier(nil), o.classifiers...),
+		})
+		o.mu.RUnlock()
+	}
+	return s
+}
+
+// restoreDomains returns the domains to s, forgetting any created since and
+// restoring the registrations of the rest in place, so that overlays already
+// returned by Domain stay in use.
+func restoreDomains(s []domainState) {
+	m := make(map[string]*Overlay, len(s))
+	for _, d := range s {
+		d.o.codes.restore(d.codes)
+		d.o.public.restore(d.public)
+		d.o.statuses.restore(d.statuses)
+		d.o.mu.Lock()
+		d.o.classifiers = append([]Classifier(nil), d.classifiers...)
+		d.o.mu.Unlock()
+		m[d.name] = d.o
+	}
+	domains.Lock()
+	domains.m = m
+	domains.Unlock()
+}
@@ -0,0 +1,52 @@
+package sherlock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestDomainInheritance(t *testing.T) {
+	errSlow := errors.New("domain test: slow")
+	errMissing := errors.New("domain test: missing")
+	errThrottled := errors.New("domain test: throttled")
+	errNotFound := errors.New("domain test: not found")
+	sherlock.Domain("dtstorage").RegisterClassifier(func(err error) error {
+		switch {
+		case errors.Is(err, errSlow):
+			return errThrottled
+		case errors.Is(err, errMissing):
+			return errNotFound
+		}
+		return nil
+	})
+	errNoSuchKey := errors.New("domain test: no such key")
+	sherlock.Domain("dtstorage.s3").RegisterClassifier(func(err error) error {
+		if errors.Is(err, errMissing) {
+			return errNoSuchKey
+		}
+		return nil
+	})
+
+	check := func(ctx context.Context, raw error) (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlock.CheckIn(ctx, raw)
+		return nil
+	}
+	s3 := sherlock.InDomain(context.Background(), "dtstorage.s3")
+	if err := check(s3, errSlow); !errors.Is(err, errThrottled) || sherlock.DomainOf(err) != "dtstorage" {
+		t.Fatalf("inherited rule gave %v from domain %q", err, sherlock.DomainOf(err))
+	}
+	if err := check(s3, errMissing); !errors.Is(err, errNoSuchKey) || errors.Is(err, errNotFound) || sherlock.DomainOf(err) != "dtstorage.s3" {
+		t.Fatalf("shadowed rule gave %v from domain %q", err, sherlock.DomainOf(err))
+	}
+	parent := sherlock.InDomain(context.Background(), "dtstorage")
+	if err := check(parent, errMissing); !errors.Is(err, errNotFound) {
+		t.Fatalf("parent domain gave %v", err)
+	}
+	if err := check(context.Background(), errMissing); sherlock.DomainOf(err) != "" {
+		t.Fatalf("error outside any domain reported domain %q", sherlock.DomainOf(err))
+	}
+}
@@ -0,0 +1,84 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Envelope is the serialised form of an error written by Encode, for carrying
+// an error's classification across process and RPC boundaries. Message and
+// Stack are redacted, and Stack is only set for errors carrying an Info, as
+// assigned by CatchAll while SetInfo is enabled.
+type Envelope struct {
+	Code        string `json:"code,omitempty"`
+	Message     string `json:"message"`
+	Public      string `json:"public,omitempty"`
+	HTTPStatus  int    `json:"http_status,omitempty"`
+	Severity    string `json:"severity,omitempty"`
+	Retryable   bool   `json:"retryable,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Stack       string `json:"stack,omitempty"`
+}
+
+// Encode serialises err, along with everything registered for it, into an
+// Envelope encoded as JSON, so that a service can return the classification of
+// an error to its callers. A nil err encodes as null.
+func Encode(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	c := Structured(err)
+	e := Envelope{
+		Code:       c.Code,
+		Message:    redact(c.Message),
+		Public:     c.Public,
+		HTTPStatus: c.HTTPStatus,
+		Severity:   SeverityOf(err).String(),
+		Retryable:  IsRetryable(err),
+	}
+	var info *Info
+	if errors.As(err, &info) {
+		e.Stack = redact(info.Stack)
+	}
+	e.Fingerprint = Fingerprint(err, e.Stack)
+	return json.Marshal(e)
+}
+
+// Decode rematerialises an error written by Encode, so that it can be handled
+// locally as though it had been raised locally. The error is a *Coded carrying
+// the envelope's code, message, public message and HTTP status, and SeverityOf
+// and IsRetryable report the envelope's severity and retryability for it. If
+// the code is registered with RegisterCodeMapping, the error also matches the
+// error registered with it, with errors.Is and in Catch, and so everything else
+// registered for it applies too. Null decodes as a nil error.
+//
+// If b is not an envelope, the error returned says so, since whatever sent it
+// meant to report a failure of some kind.
+func Decode(b []byte) error {
+	var e *Envelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return fmt.Errorf("sherlock: malformed error envelope: %w", err)
+	}
+	if e == nil {
+		return nil
+	}
+	r := &remote{severity: SeverityError, retryable: e.Retryable}
+	if sev, ok := parseSeverity(e.Severity); ok {
+		r.severity = sev
+	}
+	if e.Code != "" {
+		if sentinel, ok := byCode(e.Code); ok {
+			r.err = sentinel
+		}
+	}
+	return &Coded{
+		Code:       e.Code,
+		Message:    e.Message,
+		Public:     e.Public,
+		HTTPStatus: e.HTTPStatus,
+		Err:        r,
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	defer sherlock.Restore(sherlock.Snapshot())
+	errThrottled := errors.New("throttled")
+	sherlock.RegisterCodeMapping(errThrottled, "envelope_throttled")
+	sherlock.RegisterSeverity(errThrottled, sherlock.SeverityWarning)
+	sherlock.RegisterRetryable(errThrottled)
+	sherlock.SetRedactor(func(s string) string {
+		return strings.ReplaceAll(s, "hunter2", "****")
+	})
+	b, err := sherlock.Encode(fmt.Errorf("login with hunter2: %w", errThrottled))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "hunter2") {
+		t.Fatalf("envelope not redacted: %s", b)
+	}
+	decoded := sherlock.Decode(b)
+	if !errors.Is(decoded, errThrottled) {
+		t.Errorf("decoded %v does not match %v", decoded, errThrottled)
+	}
+	if sev := sherlock.SeverityOf(decoded); sev != sherlock.SeverityWarning {
+		t.Errorf("decoded severity %v, want %v", sev, sherlock.SeverityWarning)
+	}
+	if !sherlock.IsRetryable(decoded) {
+		t.Error("decoded error is not retryable")
+	}
+	if err := sherlock.Decode([]byte("null")); err != nil {
+		t.Errorf("null decoded as %v", err)
+	}
+}
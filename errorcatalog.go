@@ -0,0 +1,83 @@
+package sherlock
+
+import "sync/atomic"
+
+// CatalogEntry describes everything registered for a single error.
+type CatalogEntry struct {
+	Error      string   `json:"error"`
+	Code       string   `json:"code,omitempty"`
+	HTTPStatus int      `json:"http_status"`
+	Public     string   `json:"public"`
+	Hint       string   `json:"hint,omitempty"`
+	Severity   Severity `json:"-"`
+	Retryable  bool     `json:"retryable"`
+	MessageKey string   `json:"message_key,omitempty"`
+}
+
+var catalogVersion int32
+
+// SetCatalogVersion tags the registry with the schema version of its rules.
+// WriteCatalog records the version in JSON catalogs it writes, and LoadCatalog
+// migrates catalogs written with an older version before loading them. The
+// version is 0 until set, and catalogs of version 0 are written as a bare list
+// of entries, as they were before catalogs had versions.
+func SetCatalogVersion(v int) {
+	atomic.StoreInt32(&catalogVersion, int32(v))
+}
+
+// Catalog returns an entry for every registered error, in registration order.
+func Catalog() []CatalogEntry {
+	var entries []CatalogEntry
+	for _, err := range registeredErrors() {
+		entries = append(entries, catalogEntry(err))
+	}
+	return entries
+}
+
+func messageKey(err error) string {
+	key, _ := messageKeys.lookup(err)
+	return key
+}
+
+func catalogEntry(err error) CatalogEntry {
+	c := Structured(err)
+	return CatalogEntry{
+		Error:      redact(err.Error()),
+		Code:       c.Code,
+		HTTPStatus: c.HTTPStatus,
+		Public:     c.Public,
+		Hint:       Hint(err),
+		Severity:   SeverityOf(err),
+		Retryable:  IsRetryable(err),
+		MessageKey: messageKey(err),
+	}
+}
+
+// registeredErrors returns every error registered in any table, each once, in
+// registration order within each table.
+func registeredErrors() []error {
+	var errs []error
+	for _, keys := range [][]error{
+		codes.registered(),
+		publicMessages.registered(),
+		httpStatuses.registered(),
+		hints.registered(),
+		severities.registered(),
+		retryable.registered(),
+		soft.registered(),
+		messageKeys.registered(),
+		exitCodes.registered(),
+		strategies.registered(),
+	} {
+	next:
+		for _, err := range keys {
+			for _, e := range errs {
+				if sameError(e, err) {
+					continue next
+				}
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
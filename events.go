@@ -0,0 +1,79 @@
+package sherlock
+
+import (
+	"sync"
+)
+
+// Event describes an error handling decision, as recorded in the audit trail,
+// along with the code and severity of the error.
+type Event struct {
+	AuditEvent
+	Code     string
+	Severity Severity
+}
+
+// eventBuffer is the number of events buffered for each subscriber.
+const eventBuffer = 64
+
+var bus struct {
+	sync.RWMutex
+	subs map[<-chan Event]subscriber
+}
+
+type subscriber struct {
+	ch     chan Event
+	filter func(Event) bool
+}
+
+// Subscribe returns a channel on which every event matching filter is
+// delivered, so that metrics, alerting and the like can each observe the flow
+// of errors. A nil filter matches every event. Events are delivered without
+// blocking the goroutine handling the error: a subscriber that falls more than
+// a few dozen events behind misses events until it catches up.
+//
+//	ch := sherlock.Subscribe(func(e sherlock.Event) bool {
+//		return e.Action == sherlock.ActionUnexpected
+//	})
+func Subscribe(filter func(Event) bool) <-chan Event {
+	ch := make(chan Event, eventBuffer)
+	bus.Lock()
+	if bus.subs == nil {
+		bus.subs = make(map[<-chan Event]subscriber)
+	}
+	bus.subs[ch] = subscriber{ch, filter}
+	bus.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivering events on ch, a channel returned by Subscribe,
+// and closes it.
+func Unsubscribe(ch <-chan Event) {
+	bus.Lock()
+	defer bus.Unlock()
+	if s, ok := bus.subs[ch]; ok {
+		delete(bus.subs, ch)
+		close(s.ch)
+	}
+}
+
+func publish(a AuditEvent) {
+	bus.RLock()
+	defer bus.RUnlock()
+	if len(bus.subs) == 0 {
+		return
+	}
+	e := Event{
+		AuditEvent: a,
+		Code:       Code(a.Err),
+		Severity:   SeverityOf(a.Err),
+	}
+	for _, s := range bus.subs {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
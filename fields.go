@@ -0,0 +1,61 @@
+package sherlock
+
+// Fields holds structured context attached to an error.
+type Fields map[string]interface{}
+
+type fielded struct {
+	err    error
+	fields Fields
+}
+
+func (f *fielded) Error() string {
+	return f.err.Error()
+}
+
+func (f *fielded) Unwrap() error {
+	return f.err
+}
+
+// WithFields returns err with fields attached, without changing its message.
+// The fields can be retrieved with FieldsOf, even after further wrapping.
+func WithFields(err error, fields Fields) error {
+	if err == nil {
+		return nil
+	}
+	return &fielded{err: err, fields: fields}
+}
+
+// AttachFields attaches fields to any error thrown through the deferring
+// function, in the same way that Annotate attaches messages. It must be
+// deferred. If the function instead returns normally with a non-nil error in
+// err, the fields are attached to that error. err may be nil for functions
+// that do not return an error.
+func AttachFields(err *error, fields Fields) {
+	r := recover()
+	if r == nil {
+		if err != nil && *err != nil {
+			*err = WithFields(*err, fields)
+		}
+		return
+	}
+	if x, ok := r.(*report); ok {
+		x.err = WithFields(x.err, fields)
+	}
+	panic(r)
+}
+
+// FieldsOf returns all of the fields attached anywhere in err's chain. Where
+// the same key was attached more than once, the value attached closest to the
+// original error wins.
+func FieldsOf(err error) Fields {
+	fields := make(Fields)
+	walk(err, func(e error) bool {
+		if f, ok := e.(*fielded); ok {
+			for k, v := range f.fields {
+				fields[k] = v
+			}
+		}
+		return false
+	})
+	return fields
+}
@@ -0,0 +1,91 @@
+package sherlock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// fingerprintFrames is the number of user frames included in a fingerprint.
+const fingerprintFrames = 3
+
+var selfPrefix = strings.TrimSuffix(funcName(), "funcName")
+
+func funcName() string {
+	pc, _, _, _ := runtime.Caller(0)
+	return runtime.FuncForPC(pc).Name()
+}
+
+// Fingerprint returns a stable identifier for an error, so that recurrences of
+// the same problem can be grouped together. It hashes the error message, with
+// any numbers normalised away, together with the names of the topmost
+// functions in stack that belong neither to the runtime nor to sherlock.
+func Fingerprint(err error, stack string) string {
+	h := sha256.New()
+	if err != nil {
+		h.Write([]byte(maskNumbers(err.Error())))
+	}
+	for _, fn := range userFrames(stack, fingerprintFrames) {
+		h.Write([]byte{0})
+		h.Write([]byte(fn))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// userFrames returns the names of up to n functions from a stack as formatted
+// by runtime/debug.Stack, skipping the runtime, sherlock itself and helpers.
+// The "created by" line naming the function that started the goroutine is not
+// a frame, and is skipped too.
+func userFrames(stack string, n int) []string {
+	var frames []string
+	for _, line := range strings.Split(stack, "\n") {
+		if len(frames) == n {
+			break
+		}
+		if line == "" || line[0] == '\t' || strings.HasPrefix(line, "goroutine ") ||
+			strings.HasPrefix(line, "created by ") {
+			continue
+		}
+		if i := strings.LastIndex(line, "("); i > 0 {
+			line = line[:i]
+		}
+		if internalFrame(line) || isHelper(line) {
+			continue
+		}
+		frames = append(frames, line)
+	}
+	return frames
+}
+
+// internalFrame reports whether the function fn belongs to the runtime or to
+// sherlock itself.
+func internalFrame(fn string) bool {
+	return fn == "panic" || strings.HasPrefix(fn, "runtime.") ||
+		strings.HasPrefix(fn, "runtime/") || strings.HasPrefix(fn, selfPrefix)
+}
+
+// panicFingerprint returns the fingerprint of a recovered panic value.
+func panicFingerprint(r interface{}, stack string) string {
+	switch x := r.(type) {
+	case *report:
+		return Fingerprint(x.err, x.stack())
+	case error:
+		return Fingerprint(x, stack)
+	}
+	return Fingerprint(errors.New(describe(r)), stack)
+}
+
+// describe returns a one line description of a recovered panic value.
+func describe(r interface{}) string {
+	switch x := r.(type) {
+	case *report:
+		return x.err.Error()
+	case error:
+		return x.Error()
+	default:
+		return fmt.Sprint(r)
+	}
+}
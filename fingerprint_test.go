@@ -0,0 +1,22 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestFingerprintIgnoresCreator(t *testing.T) {
+	err := errors.New("failed")
+	stack := "goroutine 7 [running]:\n" +
+		"example.com/app.work()\n\t/app/work.go:12 +0x1d\n"
+	for _, creator := range []string{
+		"created by example.com/app.start in goroutine 1\n\t/app/start.go:5 +0x25\n",
+		"created by example.com/app.serve in goroutine 1\n\t/app/serve.go:9 +0x31\n",
+	} {
+		if got, want := sherlock.Fingerprint(err, stack+creator), sherlock.Fingerprint(err, stack); got != want {
+			t.Fatalf("fingerprint changed by %q", creator)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package sherlock
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// fuzzyThreshold holds the bits of the threshold set with SetFuzzyMatch.
+var fuzzyThreshold uint64
+
+// SetFuzzyMatch enables matching thrown errors to registered errors with
+// similar messages, for when an upstream library rewords an error between
+// versions. When threshold is above zero, a thrown error that no classifier
+// recognises and that matches no registered error is compared with the message
+// of every registered error. If the closest has a similarity of at least
+// threshold, the thrown error is treated as that error, as if a classifier had
+// returned it, and the near miss is counted in Counters.NearMisses and written
+// as a warning so that the registration can be updated.
+//
+// Similarity is the proportion of words that two messages share, ignoring case
+// and numbers, from 0 for none to 1 for all. Each error in the thrown error's
+// chain is compared, so wrapping does not dilute a match. A threshold of zero
+// or less disables fuzzy matching, which is the default.
+func SetFuzzyMatch(threshold float64) {
+	atomic.StoreUint64(&fuzzyThreshold, math.Float64bits(threshold))
+}
+
+// nearest returns the registered error closest to err by message, if err
+// matches no registered error and fuzzy matching finds one close enough.
+func nearest(err error) error {
+	threshold := math.Float64frombits(atomic.LoadUint64(&fuzzyThreshold))
+	if threshold <= 0 {
+		return nil
+	}
+	keys, gen := fuzzyKeys()
+	for _, key := range keys {
+		if is(err, key) {
+			return nil
+		}
+	}
+	var best error
+	var bestScore float64
+	var bestMsg string
+	walk(err, func(e error) bool {
+		msg := e.Error()
+		if key, score := closest(msg, keys, gen); score > bestScore {
+			best, bestScore, bestMsg = key, score, msg
+		}
+		return false
+	})
+	if best == nil || bestScore < threshold {
+		return nil
+	}
+	atomic.AddUint64(&counters.nearMisses, 1)
+	emit(Entry{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("sherlock: %q matched the registration of %q by similarity %.2f", bestMsg, best.Error(), bestScore),
+	})
+	return best
+}
+
+// fuzzyCache remembers the registered errors and the closest of them to
+// recently seen messages, as comparing a message with every registration is
+// slow and a reworded error tends to be thrown over and over. A message's
+// closest registration depends only on its text, so errors that share a
+// message share the entry. The cache is emptied whenever a registration
+// changes.
+var fuzzyCache struct {
+	sync.Mutex
+	gen   uint64
+	keys  []error
+	order *list.List // of *fuzzyResult, most recently used first
+	m     map[string]*list.Element
+}
+
+const fuzzyCacheSize = 128
+
+type fuzzyResult struct {
+	msg   string
+	key   error
+	score float64
+}
+
+// fuzzyKeys returns the registered errors and the generation of the
+// registrations they were read at, refreshing the cache if the registrations
+// have changed since it was filled.
+func fuzzyKeys() ([]error, uint64) {
+	gen := atomic.LoadUint64(&generation)
+	fuzzyCache.Lock()
+	defer fuzzyCache.Unlock()
+	if fuzzyCache.order == nil || fuzzyCache.gen != gen {
+		fuzzyCache.gen = gen
+		fuzzyCache.keys = registeredErrors()
+		fuzzyCache.order = list.New()
+		fuzzyCache.m = make(map[string]*list.Element)
+	}
+	return fuzzyCache.keys, gen
+}
+
+// closest returns the error in keys, the registered errors at generation gen,
+// whose message is most similar to msg, and their similarity.
+func closest(msg string, keys []error, gen uint64) (error, float64) {
+	fuzzyCache.Lock()
+	if el, ok := fuzzyCache.m[msg]; ok && fuzzyCache.gen == gen {
+		fuzzyCache.order.MoveToFront(el)
+		r := el.Value.(*fuzzyResult)
+		fuzzyCache.Unlock()
+		return r.key, r.score
+	}
+	fuzzyCache.Unlock()
+
+	r := &fuzzyResult{msg: msg}
+	words := wordSet(msg)
+	for _, key := range keys {
+		if score := similarity(words, wordSet(key.Error())); score > r.score {
+			r.key, r.score = key, score
+		}
+	}
+
+	fuzzyCache.Lock()
+	if fuzzyCache.gen == gen && fuzzyCache.m[msg] == nil {
+		fuzzyCache.m[msg] = fuzzyCache.order.PushFront(r)
+		if fuzzyCache.order.Len() > fuzzyCacheSize {
+			oldest := fuzzyCache.order.Remove(fuzzyCache.order.Back()).(*fuzzyResult)
+			delete(fuzzyCache.m, oldest.msg)
+		}
+	}
+	fuzzyCache.Unlock()
+	return r.key, r.score
+}
+
+// wordSet returns the distinct words of msg, lower cased, with numbers masked.
+func wordSet(msg string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(maskNumbers(msg)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#'
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// similarity returns the Jaccard index of two sets of words.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
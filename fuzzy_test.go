@@ -0,0 +1,62 @@
+package sherlock_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	sherlock.SetOutput(io.Discard)
+	defer sherlock.SetBackend(nil)
+	errQuota := errors.New("upstream quota exceeded for account")
+	sherlock.RegisterCodeMapping(errQuota, "fuzzy_quota")
+	sherlock.SetFuzzyMatch(0.6)
+	defer sherlock.SetFuzzyMatch(0)
+
+	throw := func(err error) (caught error) {
+		defer sherlock.CatchAll(&caught)
+		sherlock.Throw(err)
+		return nil
+	}
+	before := sherlock.Stats().NearMisses
+	reworded := fmt.Errorf("billing: %w", errors.New("Upstream quota exceeded for account 42"))
+	if code := sherlock.Code(throw(reworded)); code != "fuzzy_quota" {
+		t.Fatalf("reworded error got code %q", code)
+	}
+	if got := sherlock.Stats().NearMisses - before; got != 1 {
+		t.Fatalf("counted %d near misses", got)
+	}
+	if code := sherlock.Code(throw(errors.New("disk full"))); code != "" {
+		t.Fatalf("unrelated error got code %q", code)
+	}
+	if code := sherlock.Code(throw(errQuota)); code != "fuzzy_quota" || sherlock.Stats().NearMisses-before != 1 {
+		t.Fatal("registered error counted as a near miss")
+	}
+}
+
+func TestFuzzyMatchFollowsRegistrations(t *testing.T) {
+	sherlock.SetOutput(io.Discard)
+	defer sherlock.SetBackend(nil)
+	sherlock.SetFuzzyMatch(0.5)
+	defer sherlock.SetFuzzyMatch(0)
+	sherlock.RegisterCodeMapping(errors.New("fuzzy cache: the widget service is unavailable"), "fuzzy_widget")
+
+	throw := func() (caught error) {
+		defer sherlock.CatchAll(&caught)
+		sherlock.Throw(errors.New("fuzzy cache: the widget service is unavailable right now"))
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if code := sherlock.Code(throw()); code != "fuzzy_widget" {
+			t.Fatalf("throw %d got code %q", i, code)
+		}
+	}
+	sherlock.RegisterCodeMapping(errors.New("fuzzy cache: the widget service is unavailable right now!"), "fuzzy_widget_now")
+	if code := sherlock.Code(throw()); code != "fuzzy_widget_now" {
+		t.Fatalf("after a closer registration got code %q", code)
+	}
+}
@@ -0,0 +1,60 @@
+package sherlock
+
+import (
+	"runtime"
+	"sync"
+)
+
+var helpers struct {
+	pcs   sync.Map // call sites of MarkHelper already marked
+	funcs sync.Map // names of helper functions
+}
+
+// MarkHelper marks the calling function as a helper, in the manner of
+// testing.T.Helper. Teams often wrap Check or Throw in convenience functions of
+// their own, and without marking them every error would be reported at the
+// wrapper. The package errors are attributed to skips helpers, as do the call
+// sites used by Inject and the error tape and fingerprints, so that each
+// identifies the code calling the helper.
+//
+//	func mustDecode(v interface{}, r io.Reader) {
+//		sherlock.MarkHelper()
+//		sherlock.Check(json.NewDecoder(r).Decode(v))
+//	}
+//
+// Marking is cheap after the first call from each site, so MarkHelper can be
+// called unconditionally on every entry to the helper.
+func MarkHelper() {
+	var pc [1]uintptr
+	if runtime.Callers(2, pc[:]) == 0 {
+		return
+	}
+	if _, ok := helpers.pcs.Load(pc[0]); ok {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pc[:]).Next()
+	helpers.funcs.Store(frame.Function, struct{}{})
+	helpers.pcs.Store(pc[0], struct{}{})
+}
+
+func isHelper(fn string) bool {
+	_, ok := helpers.funcs.Load(fn)
+	return ok
+}
+
+// callSite returns the name of the function skip frames above the caller of
+// callSite, as runtime.Caller counts them, or of the first function above that
+// which is not a helper.
+func callSite(skip int) string {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	name := ""
+	for {
+		frame, more := frames.Next()
+		name = frame.Function
+		if !more || !isHelper(name) {
+			return name
+		}
+	}
+}
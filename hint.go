@@ -0,0 +1,28 @@
+package sherlock
+
+var hints table[string]
+
+// RegisterHint attaches a remediation hint to err, such as "check that the DSN
+// includes sslmode=disable". Hints are included in diagnostics and crash
+// reports, and can be retrieved with Hint.
+func RegisterHint(err error, hint string) {
+	hints.set(err, hint)
+}
+
+// Hint returns the hint registered for err, or the empty string if there is
+// none.
+func Hint(err error) string {
+	hint, _ := hints.lookup(err)
+	return hint
+}
+
+// panicHint returns the hint for a recovered panic value.
+func panicHint(r interface{}) string {
+	switch x := r.(type) {
+	case *report:
+		return Hint(x.err)
+	case error:
+		return Hint(x)
+	}
+	return ""
+}
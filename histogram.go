@@ -0,0 +1,170 @@
+package sherlock
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	histogramBucket  = time.Minute
+	histogramBuckets = 60
+	// histogramWidth limits the distinct messages counted per bucket, so that
+	// errors with dynamic messages cannot grow the histogram without bound.
+	histogramWidth = 1000
+	// histogramOther collects the messages that do not fit in a bucket.
+	histogramOther = "(other)"
+)
+
+// ErrorCount is the number of times an error message was thrown.
+type ErrorCount struct {
+	Message string
+	Count   uint64
+}
+
+var histogram struct {
+	sync.Mutex
+	buckets [histogramBuckets]struct {
+		start  time.Time
+		counts map[string]uint64
+	}
+}
+
+// countHits is set while rule hits are counted, so that matching thrown errors
+// against every registered error costs nothing otherwise.
+var countHits int32
+
+// RuleHit is the number of thrown errors that matched a registered error.
+type RuleHit struct {
+	Err  error
+	Hits uint64
+}
+
+// SetRuleHits enables or disables counting, for every registered error, the
+// thrown errors that match it, as reported by RuleHits. Each thrown error is
+// counted against the first registered error it matches. Counting is disabled
+// by default, as it matches every thrown error against the registered errors,
+// and DebugHandler enables it.
+func SetRuleHits(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&countHits, v)
+}
+
+// RuleHits returns every registered error, in registration order, with the
+// number of thrown errors counted against it while SetRuleHits was enabled. A
+// registered error with no hits is one that nothing has thrown, which in a test
+// suite is a registration the tests never exercised.
+func RuleHits() []RuleHit {
+	var out []RuleHit
+	for _, err := range registeredErrors() {
+		out = append(out, RuleHit{err, hits(err)})
+	}
+	return out
+}
+
+// ruleHits counts the thrown errors matching each registered error.
+var ruleHits struct {
+	sync.Mutex
+	rules []ruleHit
+}
+
+type ruleHit struct {
+	err error
+	n   uint64
+}
+
+// hit counts err against the first registered error it matches.
+func hit(err error) {
+	for _, key := range registeredErrors() {
+		if !is(err, key) {
+			continue
+		}
+		ruleHits.Lock()
+		defer ruleHits.Unlock()
+		for i := range ruleHits.rules {
+			if sameError(ruleHits.rules[i].err, key) {
+				ruleHits.rules[i].n++
+				return
+			}
+		}
+		ruleHits.rules = append(ruleHits.rules, ruleHit{key, 1})
+		return
+	}
+}
+
+// hits returns the number of thrown errors counted against the registered
+// error err.
+func hits(err error) uint64 {
+	ruleHits.Lock()
+	defer ruleHits.Unlock()
+	for _, r := range ruleHits.rules {
+		if sameError(r.err, err) {
+			return r.n
+		}
+	}
+	return 0
+}
+
+// tally counts a thrown error in the histogram and against any error budgets
+// and, if SetRuleHits is enabled, against the registered error it matches.
+func tally(err error) {
+	if err == nil {
+		return
+	}
+	spend(err)
+	if atomic.LoadInt32(&countHits) != 0 {
+		hit(err)
+	}
+	msg := err.Error()
+	now := time.Now().Truncate(histogramBucket)
+	histogram.Lock()
+	defer histogram.Unlock()
+	b := &histogram.buckets[now.Unix()/int64(histogramBucket/time.Second)%histogramBuckets]
+	if !b.start.Equal(now) {
+		b.start = now
+		b.counts = make(map[string]uint64)
+	}
+	if _, ok := b.counts[msg]; !ok && len(b.counts) >= histogramWidth {
+		msg = histogramOther
+	}
+	b.counts[msg]++
+}
+
+// TopErrors returns the n most frequently thrown error messages within the last
+// d, most frequent first. Counts are kept per minute for the last hour, so d is
+// rounded up to a whole minute and capped at an hour.
+func TopErrors(n int, d time.Duration) []ErrorCount {
+	if d > histogramBucket*histogramBuckets {
+		d = histogramBucket * histogramBuckets
+	}
+	since := time.Now().Truncate(histogramBucket).Add(-d)
+	totals := make(map[string]uint64)
+	histogram.Lock()
+	for _, b := range histogram.buckets {
+		if b.counts == nil || b.start.Before(since) {
+			continue
+		}
+		for msg, count := range b.counts {
+			totals[redact(msg)] += count
+		}
+	}
+	histogram.Unlock()
+	top := make([]ErrorCount, 0, len(totals))
+	for msg, count := range totals {
+		top = append(top, ErrorCount{Message: msg, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Message < top[j].Message
+	})
+	if n >= 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
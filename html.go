@@ -0,0 +1,65 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// ErrorPage is the data an HTML error page template is executed with. Stack is
+// only set when debugging is enabled, and holds the stack the error was thrown
+// from.
+type ErrorPage struct {
+	Status  int
+	Title   string
+	Message string
+	Code    string
+	Stack   string
+}
+
+// DefaultErrorPage is the template used by RenderHTML when none is given.
+var DefaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Stack}}<details open><summary>Stack</summary><pre>{{.Stack}}</pre></details>
+{{end}}</body>
+</html>
+`))
+
+// RenderHTML returns a Renderer that renders errors as HTML pages by executing
+// tmpl with an ErrorPage, for server-rendered applications. A nil tmpl uses
+// DefaultErrorPage. If debug is set the page also includes the stack the error
+// was thrown from, which must never be enabled in production.
+func RenderHTML(tmpl *template.Template, debug bool) Renderer {
+	if tmpl == nil {
+		tmpl = DefaultErrorPage
+	}
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		c := StructuredContext(r.Context(), err)
+		page := ErrorPage{
+			Status:  c.HTTPStatus,
+			Title:   http.StatusText(c.HTTPStatus),
+			Message: c.Public,
+			Code:    c.Code,
+		}
+		if debug {
+			if x, ok := r.Context().Value(stackKey{}).(*report); ok {
+				page.Stack = redact(normalizeStack(x.stack()))
+			}
+		}
+		var b bytes.Buffer
+		if terr := tmpl.Execute(&b, page); terr != nil {
+			emit(Entry{Severity: SeverityError, Message: "sherlock: could not render error page: " + terr.Error()})
+			RenderText(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(c.HTTPStatus)
+		w.Write(b.Bytes())
+	}
+}
@@ -0,0 +1,142 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+type stackKey struct{}
+
+// Renderer writes the response for an error caught while serving a request.
+type Renderer func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware recovers sherlock panics raised by next, so that handlers can use
+// Check and Throw directly, and renders the caught error with RenderText. It
+// is shorthand for Recoverer(RenderText)(next).
+func Middleware(next http.Handler) http.Handler {
+	return Recoverer(RenderText)(next)
+}
+
+// Recoverer returns middleware that recovers sherlock panics raised by the
+// handler it wraps and renders the caught error with render. Errors without a
+// registered HTTP status are rendered as 500 Internal Server Error and logged
+// along with the stack they were thrown from, in place of the diagnostic
+// CatchAll would write, which other caught errors get instead. Panics that were not raised by
+// sherlock are handled according to the foreign policy, as in CatchAll, and
+// http.ErrAbortHandler is always rethrown.
+func Recoverer(render Renderer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			var thrown *report
+			slot := new(overlaySlot)
+			r = r.WithContext(context.WithValue(r.Context(), overlaySlotKey{}, slot))
+			func() {
+				defer traceRegion(r.Context(), "Recoverer").End()
+				defer func() {
+					v := recover()
+					if v == nil {
+						return
+					}
+					if v == http.ErrAbortHandler {
+						panic(v)
+					}
+					if x, ok := v.(*report); ok && x.id == "" {
+						x.id = correlationID(r.Context())
+					}
+					thrown = catchQuietly(v, &err)
+				}()
+				next.ServeHTTP(w, r)
+			}()
+			if err == nil {
+				return
+			}
+			if slot.chain != nil {
+				r = r.WithContext(context.WithValue(r.Context(), overlayKey{}, slot.chain))
+			}
+			if thrown != nil {
+				r = r.WithContext(context.WithValue(r.Context(), stackKey{}, thrown))
+			}
+			c := StructuredContext(r.Context(), err)
+			if c.HTTPStatus >= http.StatusInternalServerError && thrown != nil {
+				diagnose(Entry{
+					Severity:      SeverityError,
+					Message:       fmt.Sprintf("%v %v: %v", r.Method, r.URL.Path, err),
+					Hint:          Hint(err),
+					Stack:         thrown.stack(),
+					CorrelationID: thrown.id,
+				})
+			} else if thrown != nil {
+				diagnose(caughtEntry(thrown))
+			}
+			render(w, r, err)
+		})
+	}
+}
+
+// RenderText renders err as plain text, using its registered HTTP status and
+// its public message. Overlays attached to the request are taken into account.
+func RenderText(w http.ResponseWriter, r *http.Request, err error) {
+	c := StructuredContext(r.Context(), err)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(c.HTTPStatus)
+	fmt.Fprintln(w, c.Public)
+}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type   string `json:"type,omitempty"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+var problemTypeBase atomic.Value
+
+// SetProblemTypeBase sets a URI that is prefixed to an error's code to form the
+// type of the problems rendered by RenderProblem. By default problems have no
+// type.
+func SetProblemTypeBase(base string) {
+	problemTypeBase.Store(base)
+}
+
+// RenderProblem renders err as an application/problem+json response, using
+// its registered HTTP status, code and public message. It can be used with
+// Recoverer to give REST APIs consistent error bodies.
+func RenderProblem(w http.ResponseWriter, r *http.Request, err error) {
+	c := StructuredContext(r.Context(), err)
+	p := Problem{
+		Title:  http.StatusText(c.HTTPStatus),
+		Status: c.HTTPStatus,
+		Detail: c.Public,
+		Code:   c.Code,
+	}
+	if base, _ := problemTypeBase.Load().(string); base != "" && c.Code != "" {
+		p.Type = base + c.Code
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(c.HTTPStatus)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Middleware attaches the overlay to every request passing through next. It
+// can be mounted on individual routes or route groups, for example with chi's
+// Router.With, and overlays attached further in take precedence over those
+// attached further out. A Recoverer mounted further out still renders with the
+// overlay.
+func (o *Overlay) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithOverlay(r.Context(), o)
+		if slot, ok := ctx.Value(overlaySlotKey{}).(*overlaySlot); ok {
+			slot.chain = ctx.Value(overlayKey{}).(*overlayChain)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,43 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestRecovererLogsOnce(t *testing.T) {
+	r := record(t)
+	h := sherlock.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sherlock.Throw(errors.New("unregistered"))
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("got status %d", w.Code)
+	}
+	if len(r.entries) != 1 || r.entries[0].Severity != sherlock.SeverityError {
+		t.Fatalf("got entries %+v", r.entries)
+	}
+}
+
+func TestRecovererCounts(t *testing.T) {
+	record(t)
+	h := sherlock.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sherlock.Throw(errors.New("unregistered"))
+	}))
+	before := sherlock.Stats()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	sherlock.SetSampler(&sherlock.Sampler{})
+	defer sherlock.SetSampler(nil)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	after := sherlock.Stats()
+	if after.Caught-before.Caught != 2 || after.Dropped-before.Dropped != 1 {
+		t.Fatalf("stats went from %+v to %+v", before, after)
+	}
+}
@@ -0,0 +1,66 @@
+package sherlock
+
+import (
+	"sync/atomic"
+)
+
+// Info describes how sherlock handled an error. When enabled with SetInfo,
+// errors assigned by CatchAll wrap an *Info, which can be retrieved with
+// errors.As:
+//
+//	var info *sherlock.Info
+//	if errors.As(err, &info) {
+//		log.Print(info.Stack)
+//	}
+type Info struct {
+	Package  string   // the import path of the package the error was thrown from
+	Severity Severity // the severity registered for the error
+	Stack    string   // the stack at the point the error was thrown
+	err      error
+}
+
+func (i *Info) Error() string {
+	return i.err.Error()
+}
+
+func (i *Info) Unwrap() error {
+	return i.err
+}
+
+var attachInfo int32
+
+// SetInfo enables or disables wrapping errors assigned by CatchAll in an
+// *Info. It is disabled by default, since wrapped errors no longer compare
+// equal to the thrown error with ==, though errors.Is continues to work.
+func SetInfo(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&attachInfo, v)
+}
+
+// withInfo returns the error to assign for x.
+func withInfo(x *report) error {
+	if atomic.LoadInt32(&attachInfo) == 0 {
+		return x.err
+	}
+	info, _ := InfoOf(x)
+	return info
+}
+
+// InfoOf returns the Info for a value recovered from a sherlock panic, for
+// code that installs its own recovery boundaries. It returns false for any
+// other value.
+func InfoOf(r interface{}) (*Info, bool) {
+	x, ok := r.(*report)
+	if !ok {
+		return nil, false
+	}
+	return &Info{
+		Package:  x.pkg,
+		Severity: SeverityOf(x.err),
+		Stack:    x.stack(),
+		err:      x.err,
+	}, true
+}
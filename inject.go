@@ -0,0 +1,73 @@
+package sherlock
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var injection struct {
+	enabled int32
+	sync.RWMutex
+	rules []injectRule
+}
+
+type injectRule struct {
+	rate  float64
+	err   error
+	match string
+}
+
+func init() {
+	if os.Getenv("SHERLOCK_INJECT") != "" {
+		injection.enabled = 1
+		*intercepts |= interceptInjection
+	}
+}
+
+// Inject adds a fault to inject for chaos testing. While injection is enabled,
+// a Check that would otherwise pass throws err instead with probability rate,
+// if the name of the function calling Check contains match. An empty match
+// matches every call site. Registered errors should be injected, so that the
+// handling of the errors the program expects is what gets exercised.
+//
+// Injection is enabled by SetInjection, or by setting the SHERLOCK_INJECT
+// environment variable to any non-empty value.
+func Inject(rate float64, err error, match string) {
+	injection.Lock()
+	injection.rules = append(injection.rules, injectRule{rate, err, match})
+	injection.Unlock()
+}
+
+// SetInjection enables or disables the faults added by Inject.
+func SetInjection(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&injection.enabled, v)
+	setIntercept(interceptInjection, enabled)
+}
+
+// injected returns the fault to inject for the Check whose caller is depth
+// frames above the caller of injected, if any.
+func injected(depth int) error {
+	if atomic.LoadInt32(&injection.enabled) == 0 {
+		return nil
+	}
+	injection.RLock()
+	rules := injection.rules
+	injection.RUnlock()
+	if len(rules) == 0 {
+		return nil
+	}
+	name := callSite(depth + 1)
+	for _, rule := range rules {
+		if strings.Contains(name, rule.match) && rand.Float64() < rule.rate {
+			return rule.err
+		}
+	}
+	return nil
+}
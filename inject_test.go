@@ -0,0 +1,58 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+var errInjected = errors.New("inject test: injected")
+
+func injectTarget(err error) (caught error) {
+	defer sherlock.CatchAll(&caught)
+	sherlock.Check(err)
+	return nil
+}
+
+func injectBystander(err error) (caught error) {
+	defer sherlock.CatchAll(&caught)
+	sherlock.Check(err)
+	return nil
+}
+
+func TestInject(t *testing.T) {
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.Inject(1, errInjected, "injectTarget")
+	if err := injectTarget(nil); err != nil {
+		t.Errorf("fault injected while disabled: %v", err)
+	}
+	sherlock.SetInjection(true)
+	if err := injectTarget(nil); !errors.Is(err, errInjected) {
+		t.Errorf("matching site threw %v, want the injected fault", err)
+	}
+	if err := injectBystander(nil); err != nil {
+		t.Errorf("other site threw %v", err)
+	}
+	errReal := errors.New("inject test: real")
+	if err := injectTarget(errReal); !errors.Is(err, errReal) || errors.Is(err, errInjected) {
+		t.Errorf("failing Check threw %v, want its own error", err)
+	}
+	sherlock.SetInjection(false)
+	if err := injectTarget(nil); err != nil {
+		t.Errorf("fault injected after disabling: %v", err)
+	}
+}
+
+func TestInjectRate(t *testing.T) {
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.Inject(0, errInjected, "")
+	sherlock.SetInjection(true)
+	for i := 0; i < 100; i++ {
+		if err := injectBystander(nil); err != nil {
+			t.Fatalf("fault injected at rate 0: %v", err)
+		}
+	}
+}
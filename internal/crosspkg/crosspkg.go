@@ -0,0 +1,31 @@
+// Package crosspkg throws and catches sherlock panics from a package of its
+// own, so that sherlock's tests can cross a package boundary.
+package crosspkg
+
+import "github.com/alankm/sherlock"
+
+var sh = sherlock.Bind()
+
+// Check checks err from this package.
+func Check(err error) {
+	sherlock.Check(err)
+}
+
+// Throw throws err through a Handle bound to this package.
+func Throw(err error) {
+	sh.Throw(err)
+}
+
+// Must is a helper that checks err on behalf of its caller.
+func Must(err error) {
+	sherlock.MarkHelper()
+	sherlock.Check(err)
+}
+
+// Caught reports whether err, thrown by this package, is caught by a Catch
+// deferred in this package.
+func Caught(err error) (caught bool) {
+	defer sherlock.Catch(err, func() { caught = true })
+	sherlock.Check(err)
+	return false
+}
@@ -0,0 +1,86 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+type journaldBackend struct {
+	conn *net.UnixConn
+	id   string
+}
+
+// JournaldBackend returns a Backend that writes diagnostics to the systemd
+// journal using its native protocol. Besides MESSAGE and PRIORITY, each entry
+// carries SHERLOCK_HINT, SHERLOCK_PACKAGE, SHERLOCK_CORRELATION_ID,
+// SHERLOCK_STACK and SHERLOCK_FINGERPRINT fields when they are known. The journal stamps entries
+// as it receives them, so each also carries SHERLOCK_TIME, the time of its
+// Entry in RFC 3339 format, which differs while SetBatching holds entries back.
+func JournaldBackend() (Backend, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldBackend{conn: conn, id: filepath.Base(os.Args[0])}, nil
+}
+
+func (b *journaldBackend) Emit(e Entry) error {
+	priority := 6 // info
+	switch {
+	case e.Severity.atLeast(SeverityFatal):
+		priority = 2 // crit
+	case e.Severity.atLeast(SeverityError):
+		priority = 3 // err
+	case e.Severity.atLeast(SeverityWarning):
+		priority = 4 // warning
+	}
+	var buf bytes.Buffer
+	journalField(&buf, "MESSAGE", e.Message)
+	journalField(&buf, "PRIORITY", strconv.Itoa(priority))
+	journalField(&buf, "SYSLOG_IDENTIFIER", b.id)
+	if !e.Time.IsZero() {
+		journalField(&buf, "SHERLOCK_TIME", e.Time.Format(time.RFC3339Nano))
+	}
+	if e.Hint != "" {
+		journalField(&buf, "SHERLOCK_HINT", e.Hint)
+	}
+	if e.Package != "" {
+		journalField(&buf, "SHERLOCK_PACKAGE", e.Package)
+	}
+	if e.CorrelationID != "" {
+		journalField(&buf, "SHERLOCK_CORRELATION_ID", e.CorrelationID)
+	}
+	if e.Source != "" {
+		journalField(&buf, "SHERLOCK_SOURCE", e.Source)
+	}
+	if e.Stack != "" {
+		journalField(&buf, "SHERLOCK_STACK", e.Stack)
+	}
+	if e.Fingerprint != "" {
+		journalField(&buf, "SHERLOCK_FINGERPRINT", e.Fingerprint)
+	}
+	_, err := b.conn.Write(buf.Bytes())
+	return err
+}
+
+// journalField encodes a single field, using the length-prefixed form for
+// values that span multiple lines.
+func journalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(key + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
@@ -0,0 +1,86 @@
+package sherlock
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var exitCodes table[int]
+
+// RegisterExitCode registers code as the process exit code to use when Main
+// ends with err.
+func RegisterExitCode(err error, code int) {
+	exitCodes.set(err, code)
+}
+
+// Main runs fn as the body of a command line program and exits the process
+// with a code derived from the error it ends with, whether the error was
+// returned or thrown. Errors with a registered exit code are expected, and are
+// reported with a clean one line message. Any other error is reported with
+// full diagnostics, recorded in the audit trail as unexpected, and exits with
+// code 1, and panics that were not raised by sherlock are considered bugs and
+// exit with code 2.
+//
+//	func main() {
+//		sherlock.Main(run)
+//	}
+func Main(fn func() error) {
+	code := run(fn)
+	Close()
+	os.Exit(code)
+}
+
+func run(fn func() error) (code int) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		x, ok := r.(*report)
+		if !ok {
+			stack := bug(r)
+			record(r, nil, ActionUnexpected)
+			coolDown(panicFingerprint(r, stack))
+			code = 2
+			return
+		}
+		code = exit(x.err, x)
+	}()
+	return exit(fn(), nil)
+}
+
+// exit reports and records err, along with the stack of x if it was thrown, and
+// returns the exit code for it.
+func exit(err error, x *report) int {
+	if err == nil {
+		return 0
+	}
+	var r interface{} = err
+	if x != nil {
+		r = x
+	}
+	if code, ok := exitCodes.lookup(err); ok {
+		if x != nil {
+			record(r, err, ActionCaught)
+		}
+		diagnose(Entry{
+			Severity: caughtSeverity(err),
+			Message:  filepath.Base(os.Args[0]) + ": " + err.Error(),
+			Hint:     Hint(err),
+		})
+		return code
+	}
+	e := Entry{
+		Severity: SeverityError,
+		Message:  err.Error(),
+		Hint:     Hint(err),
+	}
+	if x != nil {
+		e.Stack = x.stack()
+		e.CorrelationID = x.id
+		e.Source = snippet(e.Stack)
+	}
+	diagnoseBug(e)
+	record(r, nil, ActionUnexpected)
+	return 1
+}
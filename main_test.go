@@ -0,0 +1,58 @@
+package sherlock
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRunRecordsUnexpected(t *testing.T) {
+	SetOutput(io.Discard)
+	defer SetBackend(nil)
+	defer SetAuditSize(0)
+	errExpected := errors.New("expected")
+	RegisterExitCode(errExpected, 3)
+	for _, tt := range []struct {
+		fn     func() error
+		code   int
+		action Action
+	}{
+		{func() error { return errors.New("returned") }, 1, ActionUnexpected},
+		{func() error { Throw(errors.New("thrown")); return nil }, 1, ActionUnexpected},
+		{func() error { Throw(errExpected); return nil }, 3, ActionCaught},
+	} {
+		SetAuditSize(10)
+		if code := run(tt.fn); code != tt.code {
+			t.Errorf("got exit code %d, want %d", code, tt.code)
+		}
+		log := AuditLog()
+		if len(log) != 1 || log[0].Action != tt.action {
+			t.Errorf("got audit log %+v, want one %v event", log, tt.action)
+		}
+	}
+}
+
+type entries []Entry
+
+func (e *entries) Emit(entry Entry) error {
+	*e = append(*e, entry)
+	return nil
+}
+
+func TestExitNotSampled(t *testing.T) {
+	var got entries
+	SetBackend(&got)
+	defer SetBackend(nil)
+	SetSampler(&Sampler{})
+	defer SetSampler(nil)
+	before := Stats()
+	if code := run(func() error { return errors.New("sampled") }); code != 1 {
+		t.Fatalf("got exit code %d", code)
+	}
+	if len(got) != 1 || got[0].Severity != SeverityError {
+		t.Fatalf("got entries %+v, want the reason for exiting", got)
+	}
+	if n := Stats().Unexpected - before.Unexpected; n != 1 {
+		t.Fatalf("counted %d unexpected errors", n)
+	}
+}
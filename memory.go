@@ -0,0 +1,79 @@
+package sherlock
+
+import (
+	"sync"
+)
+
+// MemoryUsage describes what sherlock is holding on to, as reported by
+// MemoryStats.
+type MemoryUsage struct {
+	// Registrations is the number of errors registered in each table, keyed
+	// by what the table registers, such as "code" or "http_status".
+	Registrations map[string]int
+	// Classifiers is the number of classifiers and matchers registered.
+	Classifiers int
+	// CallSites is the number of call sites whose package has been resolved
+	// and cached.
+	CallSites int
+	// Helpers is the number of functions marked with MarkHelper.
+	Helpers int
+}
+
+// MemoryStats reports the size of every registration table and cache, for
+// long-running processes that want to keep an eye on them after bulk changes
+// to their registrations.
+func MemoryStats() MemoryUsage {
+	u := MemoryUsage{
+		Registrations: map[string]int{
+			"code":        len(codes.load().keys),
+			"public":      len(publicMessages.load().keys),
+			"http_status": len(httpStatuses.load().keys),
+			"hint":        len(hints.load().keys),
+			"severity":    len(severities.load().keys),
+			"retryable":   len(retryable.load().keys),
+			"soft":        len(soft.load().keys),
+			"message_key": len(messageKeys.load().keys),
+			"exit_code":   len(exitCodes.load().keys),
+			"strategy":    len(strategies.load().keys),
+		},
+		CallSites: count(&callers),
+		Helpers:   count(&helpers.funcs),
+	}
+	classifiers.RLock()
+	u.Classifiers = len(classifiers.fns)
+	classifiers.RUnlock()
+	return u
+}
+
+// Shrink drops sherlock's caches and compacts its registration tables, for
+// after bulk changes to registrations. The caches are rebuilt as call sites
+// are next used, and functions stay marked as helpers.
+func Shrink() {
+	callers.Range(func(k, _ interface{}) bool {
+		callers.Delete(k)
+		return true
+	})
+	helpers.pcs.Range(func(k, _ interface{}) bool {
+		helpers.pcs.Delete(k)
+		return true
+	})
+	codes.shrink()
+	publicMessages.shrink()
+	httpStatuses.shrink()
+	hints.shrink()
+	severities.shrink()
+	retryable.shrink()
+	soft.shrink()
+	messageKeys.shrink()
+	exitCodes.shrink()
+	strategies.shrink()
+}
+
+func count(m *sync.Map) int {
+	n := 0
+	m.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
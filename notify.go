@@ -0,0 +1,134 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Notifier posts a summary to a webhook once the number of unexpected panics
+// seen within Window reaches Threshold. Every panic seen in the window is
+// batched into a single message, and once a message has been sent no further
+// messages are sent until Cooldown has passed. Without a Window, panics are
+// counted from the last message sent, and only the most recent are kept to be
+// listed in the next one. The payload is compatible with Slack incoming
+// webhooks.
+type Notifier struct {
+	URL       string
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+	Client    *http.Client
+
+	mu     sync.Mutex
+	events []notification
+	// earlier counts the events dropped from events without a Window.
+	earlier int
+	sent    time.Time
+}
+
+type notification struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+type notifierPayload struct {
+	Text   string         `json:"text"`
+	Count  int            `json:"count"`
+	Events []notification `json:"events"`
+}
+
+// notifyMax limits the number of individual events listed in a message.
+const notifyMax = 10
+
+var notifier struct {
+	sync.Mutex
+	n *Notifier
+}
+
+// SetNotifier installs n as the webhook notifier for unexpected panics. A nil
+// n disables notifications, which is the default.
+func SetNotifier(n *Notifier) {
+	notifier.Lock()
+	notifier.n = n
+	notifier.Unlock()
+}
+
+func notify(r interface{}, stack string) {
+	notifier.Lock()
+	n := notifier.n
+	notifier.Unlock()
+	if n != nil {
+		n.observe(time.Now(), fmt.Sprintf("%v [%v]", redact(describe(r)), panicFingerprint(r, stack)))
+	}
+}
+
+func (n *Notifier) observe(now time.Time, msg string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Window > 0 {
+		i := 0
+		for i < len(n.events) && now.Sub(n.events[i].Time) > n.Window {
+			i++
+		}
+		n.events = n.events[i:]
+	} else if len(n.events) == notifyMax {
+		copy(n.events, n.events[1:])
+		n.events = n.events[:notifyMax-1]
+		n.earlier++
+	}
+	n.events = append(n.events, notification{Time: now, Message: msg})
+	count := n.earlier + len(n.events)
+	if count < n.Threshold {
+		return
+	}
+	if !n.sent.IsZero() && now.Sub(n.sent) < n.Cooldown {
+		return
+	}
+	p := notifierPayload{
+		Text:   fmt.Sprintf("sherlock: %v unexpected errors", count),
+		Count:  count,
+		Events: n.events,
+	}
+	if n.Window > 0 {
+		p.Text += fmt.Sprintf(" in the last %v", n.Window)
+	}
+	if len(p.Events) > notifyMax {
+		p.Events = p.Events[len(p.Events)-notifyMax:]
+	}
+	for _, e := range p.Events {
+		p.Text += "\n• " + e.Message
+	}
+	if count > len(p.Events) {
+		p.Text += fmt.Sprintf("\n… and %v more", count-len(p.Events))
+	}
+	n.events, n.earlier = nil, 0
+	n.sent = now
+	go n.post(p)
+}
+
+func (n *Notifier) post(p notifierPayload) {
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, err := json.Marshal(p)
+	if err == nil {
+		var resp *http.Response
+		resp, err = client.Post(n.URL, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode/100 != 2 {
+				err = fmt.Errorf("unexpected status %v", resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		emit(Entry{Severity: SeverityError, Message: fmt.Sprintf("sherlock: could not send notification: %v", err)})
+	}
+}
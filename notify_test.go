@@ -0,0 +1,26 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestNotifierBoundedWithoutWindow(t *testing.T) {
+	SetOutput(io.Discard)
+	defer SetBackend(nil)
+	n := &Notifier{URL: "http://127.0.0.1:0", Threshold: 1, Cooldown: time.Hour, sent: time.Now()}
+	now := time.Now()
+	for i := 0; i < 10*notifyMax; i++ {
+		n.observe(now, fmt.Sprint("panic ", i))
+	}
+	if len(n.events) != notifyMax || n.earlier != 9*notifyMax {
+		t.Fatalf("kept %d events and counted %d earlier", len(n.events), n.earlier)
+	}
+	if last := n.events[len(n.events)-1].Message; last != fmt.Sprint("panic ", 10*notifyMax-1) {
+		t.Fatalf("got last event %q", last)
+	}
+}
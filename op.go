@@ -0,0 +1,63 @@
+package sherlock
+
+// Op names the operation being performed, such as "db.SaveUser". Passed to
+// Check, or deferred through Scope, it is embedded in thrown errors so that
+// they read like "db.SaveUser: connection refused", and the chain of
+// operations can be retrieved with Ops.
+//
+//	sherlock.Check(sherlock.Op("db.SaveUser"), db.Exec(query))
+type Op string
+
+type opError struct {
+	op  Op
+	err error
+}
+
+func (e *opError) Error() string {
+	return string(e.op) + ": " + e.err.Error()
+}
+
+func (e *opError) Unwrap() error {
+	return e.err
+}
+
+// Scope embeds op in any error thrown through the deferring function, and in
+// any non-nil error the function returns in err. It must be deferred, and err
+// may be nil for functions that do not return an error.
+//
+//	defer sherlock.Op("db.SaveUser").Scope(&err)
+func (op Op) Scope(err *error) {
+	r := recover()
+	if r == nil {
+		if err != nil && *err != nil {
+			*err = &opError{op: op, err: *err}
+		}
+		return
+	}
+	if x, ok := r.(*report); ok {
+		x.err = &opError{op: op, err: x.err}
+	}
+	panic(r)
+}
+
+// Ops returns the operations embedded in err, outermost first.
+func Ops(err error) []Op {
+	var ops []Op
+	walk(err, func(e error) bool {
+		if x, ok := e.(*opError); ok {
+			ops = append(ops, x.op)
+		}
+		return false
+	})
+	return ops
+}
+
+// withOps wraps err in every Op found in args, with the first outermost.
+func withOps(args []interface{}, err error) error {
+	for i := len(args) - 1; i >= 0; i-- {
+		if op, ok := args[i].(Op); ok {
+			err = &opError{op: op, err: err}
+		}
+	}
+	return err
+}
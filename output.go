@@ -0,0 +1,112 @@
+package sherlock
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Entry is a single diagnostic written by sherlock. Hint, Package and Stack are
+// only filled in when they are known, and Fingerprint only for panics that
+// sherlock considers to be bugs. Time is when the diagnostic was produced,
+// which can be earlier than when a backend receives it if SetBatching is
+// used, and is zero in deterministic mode. CorrelationID is set for errors
+// thrown or caught in a context carrying one, as described by
+// SetCorrelationKey.
+type Entry struct {
+	Time          time.Time
+	Severity      Severity
+	CorrelationID string
+	Message       string
+	Hint          string
+	Package       string
+	Stack         string
+	Source        string
+	Fingerprint   string
+}
+
+// Backend receives every diagnostic that sherlock writes. By default
+// diagnostics are written to stderr.
+type Backend interface {
+	Emit(e Entry) error
+}
+
+type writerBackend struct {
+	w io.Writer
+}
+
+// WriterBackend returns a Backend that writes diagnostics as plain text to w.
+func WriterBackend(w io.Writer) Backend {
+	return writerBackend{w: w}
+}
+
+func (b writerBackend) Emit(e Entry) error {
+	var err error
+	if e.Message != "" {
+		_, err = fmt.Fprintf(b.w, "%v\n", e.Message)
+	}
+	if err == nil && e.Hint != "" {
+		_, err = fmt.Fprintf(b.w, "hint: %v\n", e.Hint)
+	}
+	if err == nil && e.CorrelationID != "" {
+		_, err = fmt.Fprintf(b.w, "correlation id: %v\n", e.CorrelationID)
+	}
+	if err == nil && e.Source != "" {
+		_, err = fmt.Fprintf(b.w, "%v\n", e.Source)
+	}
+	if err == nil && e.Stack != "" {
+		_, err = fmt.Fprintf(b.w, "%v\n", e.Stack)
+	}
+	return err
+}
+
+var output = struct {
+	sync.Mutex
+	b Backend
+}{b: defaultBackend()}
+
+// SetOutput sets the writer that diagnostics are written to.
+func SetOutput(w io.Writer) {
+	SetBackend(WriterBackend(w))
+}
+
+// SetBackend sets the backend that diagnostics are written to. A nil backend
+// discards all diagnostics.
+func SetBackend(b Backend) {
+	output.Lock()
+	output.b = b
+	output.Unlock()
+}
+
+// emit writes e to the configured backend, falling back to the platform's
+// default backend if it fails.
+func emit(e Entry) {
+	output.Lock()
+	b := output.b
+	output.Unlock()
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = timestamp(time.Now())
+	}
+	e.Message = redact(e.Message)
+	e.Hint = redact(e.Hint)
+	e.Stack = redact(normalizeStack(e.Stack))
+	e.Source = redact(e.Source)
+	if batched(e) {
+		return
+	}
+	send(b, e)
+}
+
+// send writes e to b, falling back to the platform's default backend if b
+// fails.
+func send(b Backend, e Entry) {
+	if err := b.Emit(e); err != nil {
+		fallback := defaultBackend()
+		fallback.Emit(Entry{Severity: SeverityError, Message: fmt.Sprintf("sherlock: backend failed: %v", err)})
+		fallback.Emit(e)
+	}
+}
@@ -0,0 +1,58 @@
+package sherlock_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+type recorder struct {
+	sync.Mutex
+	entries []sherlock.Entry
+}
+
+func (r *recorder) Emit(e sherlock.Entry) error {
+	r.Lock()
+	r.entries = append(r.entries, e)
+	r.Unlock()
+	return nil
+}
+
+func record(t *testing.T) *recorder {
+	r := new(recorder)
+	sherlock.SetBackend(r)
+	t.Cleanup(func() { sherlock.SetBackend(nil) })
+	return r
+}
+
+func TestEntryTime(t *testing.T) {
+	r := record(t)
+	before := time.Now()
+	sherlock.Ok(errors.New("stamped"))
+	if len(r.entries) != 1 {
+		t.Fatalf("got %d entries", len(r.entries))
+	}
+	if at := r.entries[0].Time; at.Before(before) || at.After(time.Now()) {
+		t.Fatalf("entry stamped %v, want time of emission", at)
+	}
+}
+
+func TestSourceRedacted(t *testing.T) {
+	r := record(t)
+	sherlock.SetSourceLines(1)
+	defer sherlock.SetSourceLines(0)
+	sherlock.SetRedactor(func(s string) string { return strings.ReplaceAll(s, "hunter2", "***") })
+	defer sherlock.SetRedactor(nil)
+	sherlock.Barrier(func() { panic("hunter2") })
+	if len(r.entries) != 1 {
+		t.Fatalf("got %d entries", len(r.entries))
+	}
+	e := r.entries[0]
+	if e.Source == "" || strings.Contains(e.Source, "hunter2") || !strings.Contains(e.Source, "***") {
+		t.Fatalf("got source %q", e.Source)
+	}
+}
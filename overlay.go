@@ -0,0 +1,122 @@
+package sherlock
+
+import (
+	"context"
+	"sync"
+)
+
+// Overlay holds registrations that take precedence over the global ones for
+// the requests it is attached to, such as stricter mappings for admin routes.
+// The zero value is an empty overlay ready to use. Overlays for named, nested
+// parts of a program are returned by Domain.
+type Overlay struct {
+	domain   string
+	codes    table[string]
+	public   table[string]
+	statuses table[int]
+
+	mu          sync.RWMutex
+	classifiers []Classifier
+}
+
+// RegisterCodeMapping registers code as the code of err within the overlay.
+func (o *Overlay) RegisterCodeMapping(err error, code string) {
+	o.codes.set(err, code)
+}
+
+// RegisterPublicMessage registers msg as the public message of err within
+// the overlay.
+func (o *Overlay) RegisterPublicMessage(err error, msg string) {
+	o.public.set(err, msg)
+}
+
+// RegisterHTTPStatus registers status as the HTTP status of err within the
+// overlay.
+func (o *Overlay) RegisterHTTPStatus(err error, status int) {
+	o.statuses.set(err, status)
+}
+
+// RegisterClassifier adds fn to the classifiers of the overlay, for mappings
+// that only apply to some requests, such as those in an experiment. Errors
+// caught while serving a request are passed through the classifiers of the
+// overlays attached to it, innermost first, before anything registered for
+// them is looked up.
+func (o *Overlay) RegisterClassifier(fn Classifier) {
+	o.mu.Lock()
+	o.classifiers = append(o.classifiers, fn)
+	o.mu.Unlock()
+}
+
+func (o *Overlay) classify(err error) error {
+	if _, ok := unannotate(err).(Known); ok {
+		return nil
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for _, fn := range o.classifiers {
+		if sentinel := consult(fn, err); sentinel != nil {
+			return &classified{err: err, sentinel: sentinel, domain: o.domain}
+		}
+	}
+	return nil
+}
+
+type overlayKey struct{}
+
+// overlaySlotKey holds an overlaySlot placed in the request context by
+// Recoverer, through which overlays attached by inner middleware are passed
+// back out to it.
+type overlaySlotKey struct{}
+
+type overlaySlot struct {
+	chain *overlayChain
+}
+
+type overlayChain struct {
+	o      *Overlay
+	parent *overlayChain
+}
+
+// WithOverlay returns a copy of ctx with o attached, taking precedence over any
+// overlays already attached to ctx.
+func WithOverlay(ctx context.Context, o *Overlay) context.Context {
+	parent, _ := ctx.Value(overlayKey{}).(*overlayChain)
+	return context.WithValue(ctx, overlayKey{}, &overlayChain{o: o, parent: parent})
+}
+
+// overlaid passes err through the classifiers of the overlays attached to ctx,
+// innermost first, returning it unchanged if none recognises it.
+func overlaid(ctx context.Context, err error) error {
+	chain, _ := ctx.Value(overlayKey{}).(*overlayChain)
+	for ; chain != nil && err != nil; chain = chain.parent {
+		if c := chain.o.classify(err); c != nil {
+			return c
+		}
+	}
+	return err
+}
+
+// StructuredContext is like Structured, but registrations in overlays attached
+// to ctx take precedence over the global ones, and err is first passed through
+// the overlays' classifiers.
+func StructuredContext(ctx context.Context, err error) *Coded {
+	err = overlaid(ctx, err)
+	c := Structured(err)
+	if c == nil {
+		return nil
+	}
+	var code, public, status bool
+	chain, _ := ctx.Value(overlayKey{}).(*overlayChain)
+	for ; chain != nil; chain = chain.parent {
+		if v, ok := chain.o.codes.lookup(err); ok && !code {
+			c.Code, code = v, true
+		}
+		if v, ok := chain.o.public.lookup(err); ok && !public {
+			c.Public, public = v, true
+		}
+		if v, ok := chain.o.statuses.lookup(err); ok && !status {
+			c.HTTPStatus, status = v, true
+		}
+	}
+	return c
+}
@@ -0,0 +1,46 @@
+package sherlock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestCheckInOverlay(t *testing.T) {
+	errRaw := errors.New("raw")
+	errMapped := errors.New("mapped")
+	o := new(sherlock.Overlay)
+	o.RegisterClassifier(func(err error) error {
+		if errors.Is(err, errRaw) {
+			return errMapped
+		}
+		return nil
+	})
+	ctx := sherlock.WithOverlay(context.Background(), o)
+	err := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlock.CheckIn(ctx, errRaw)
+		return nil
+	}()
+	if !errors.Is(err, errMapped) {
+		t.Fatalf("got %v, want it to match %v", err, errMapped)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	o.RegisterClassifier(func(err error) error {
+		if errors.Is(err, context.Canceled) {
+			return errMapped
+		}
+		return nil
+	})
+	err = func() (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlock.CheckCtx(canceled)
+		return nil
+	}()
+	if !errors.Is(err, errMapped) {
+		t.Fatalf("got %v from CheckCtx, want it to match %v", err, errMapped)
+	}
+}
@@ -0,0 +1,108 @@
+package sherlock
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Overrides are temporary changes to registrations and settings, applied with
+// PushOverrides.
+type Overrides struct {
+	Codes         map[error]string
+	Public        map[error]string
+	HTTPStatuses  map[error]int
+	Classifiers   []Classifier
+	ForeignPolicy *Policy
+	Strict        *bool
+	Overwrite     *bool
+}
+
+var overrides struct {
+	sync.Mutex
+	stack [][]func()
+	next  uint64 // the handle of the classifiers added by the last push
+}
+
+// PushOverrides applies o on top of the current registrations and settings,
+// saving what it replaces so that the matching Pop restores it. Deferring Pop
+// restores the previous behaviour even if the code in between panics.
+//
+//	sherlock.PushOverrides(sherlock.Overrides{ForeignPolicy: &policy})
+//	defer sherlock.Pop()
+func PushOverrides(o Overrides) {
+	overrides.Lock()
+	defer overrides.Unlock()
+	var undo []func()
+	for err, code := range o.Codes {
+		undo = append(undo, codes.override(err, code))
+	}
+	for err, msg := range o.Public {
+		undo = append(undo, publicMessages.override(err, msg))
+	}
+	for err, status := range o.HTTPStatuses {
+		undo = append(undo, httpStatuses.override(err, status))
+	}
+	if len(o.Classifiers) > 0 {
+		overrides.next++
+		handle := overrides.next
+		handles := make([]uint64, len(o.Classifiers))
+		for i := range handles {
+			handles[i] = handle
+		}
+		classifiers.Lock()
+		classifiers.fns = append(append([]Classifier(nil), o.Classifiers...), classifiers.fns...)
+		classifiers.handles = append(handles, classifiers.handles...)
+		classifiers.Unlock()
+		undo = append(undo, func() { removeClassifiers(handle) })
+	}
+	if o.ForeignPolicy != nil {
+		prev := Policy(atomic.LoadInt32(&foreignPolicy))
+		SetForeignPolicy(*o.ForeignPolicy)
+		undo = append(undo, func() { SetForeignPolicy(prev) })
+	}
+	if o.Strict != nil {
+		prev := atomic.LoadInt32(&strict) != 0
+		SetStrict(*o.Strict)
+		undo = append(undo, func() { SetStrict(prev) })
+	}
+	if o.Overwrite != nil {
+		prev := atomic.LoadInt32(&overwrite) != 0
+		SetOverwrite(*o.Overwrite)
+		undo = append(undo, func() { SetOverwrite(prev) })
+	}
+	overrides.stack = append(overrides.stack, undo)
+}
+
+// Pop undoes the changes made by the most recent PushOverrides that has not yet
+// been popped, and nothing else, so that registrations and settings made in the
+// meantime are kept. It does nothing if there is none.
+func Pop() {
+	overrides.Lock()
+	defer overrides.Unlock()
+	n := len(overrides.stack)
+	if n == 0 {
+		return
+	}
+	undo := overrides.stack[n-1]
+	overrides.stack = overrides.stack[:n-1]
+	for i := len(undo) - 1; i >= 0; i-- {
+		undo[i]()
+	}
+}
+
+// removeClassifiers removes the classifiers added by the PushOverrides given
+// handle. Classifiers are identified by the handle they were added with rather
+// than by their code, since distinct closures share the same code.
+func removeClassifiers(handle uint64) {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+	var fns []Classifier
+	var handles []uint64
+	for i, h := range classifiers.handles {
+		if h != handle {
+			fns = append(fns, classifiers.fns[i])
+			handles = append(handles, h)
+		}
+	}
+	classifiers.fns, classifiers.handles = fns, handles
+}
@@ -0,0 +1,97 @@
+package sherlock_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestPopKeepsOtherChanges(t *testing.T) {
+	errOverridden := errors.New("overridden")
+	errAdded := errors.New("added")
+	errPrevious := errors.New("previous")
+	sherlock.RegisterCodeMapping(errPrevious, "previous")
+	classifiers := sherlock.MemoryStats().Classifiers
+	sherlock.PushOverrides(sherlock.Overrides{
+		Codes: map[error]string{
+			errOverridden: "override",
+			errPrevious:   "override",
+		},
+		Classifiers: []sherlock.Classifier{func(error) error { return nil }},
+	})
+	sherlock.RegisterCodeMapping(errAdded, "added")
+	sherlock.Pop()
+	for err, want := range map[error]string{errOverridden: "", errPrevious: "previous", errAdded: "added"} {
+		if got := sherlock.Code(err); got != want {
+			t.Errorf("Code(%v) = %q, want %q", err, got, want)
+		}
+	}
+	if n := sherlock.MemoryStats().Classifiers; n != classifiers {
+		t.Errorf("got %v classifiers after Pop, want %v", n, classifiers)
+	}
+}
+
+func TestPopClosures(t *testing.T) {
+	errOuter := errors.New("overrides test: outer")
+	errInner := errors.New("overrides test: inner")
+	errRaw := errors.New("overrides test: raw")
+	as := func(sentinel error) sherlock.Classifier {
+		return func(err error) error {
+			if errors.Is(err, errRaw) {
+				return sentinel
+			}
+			return nil
+		}
+	}
+	check := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlock.Check(errRaw)
+		return nil
+	}
+	sherlock.PushOverrides(sherlock.Overrides{Classifiers: []sherlock.Classifier{as(errOuter)}})
+	defer sherlock.Pop()
+	sherlock.PushOverrides(sherlock.Overrides{Classifiers: []sherlock.Classifier{as(errInner)}})
+	if err := check(); !errors.Is(err, errInner) {
+		t.Fatalf("got %v, want it classified by the inner override", err)
+	}
+	sherlock.Pop()
+	if err := check(); !errors.Is(err, errOuter) {
+		t.Errorf("got %v after Pop, want it classified by the outer override", err)
+	}
+
+	// Popping an override whose classifiers are already gone must not
+	// remove another's that shares their code.
+	s := sherlock.Snapshot()
+	sherlock.PushOverrides(sherlock.Overrides{Classifiers: []sherlock.Classifier{as(errInner)}})
+	sherlock.Restore(s)
+	sherlock.Pop()
+	if err := check(); !errors.Is(err, errOuter) {
+		t.Errorf("got %v after popping a restored override, want it classified by the outer override", err)
+	}
+}
+
+func TestPopSettings(t *testing.T) {
+	on := true
+	sherlock.PushOverrides(sherlock.Overrides{Strict: &on, Overwrite: &on})
+	if r := thrown(func() { sherlock.Check("not an error") }); r == nil {
+		t.Error("strict checking not enabled by the override")
+	}
+	overwritten := func() (err error) {
+		err = errors.New("overrides test: first")
+		defer sherlock.CatchAll(&err)
+		sherlock.Throw(errors.New("overrides test: second"))
+		return nil
+	}
+	if err := overwritten(); strings.Contains(err.Error(), "first") {
+		t.Errorf("overwriting not enabled by the override: %v", err)
+	}
+	sherlock.Pop()
+	if r := thrown(func() { sherlock.Check("not an error") }); r != nil {
+		t.Errorf("strict checking survived Pop: %v", r)
+	}
+	if err := overwritten(); !strings.Contains(err.Error(), "first") {
+		t.Errorf("overwriting survived Pop: %v", err)
+	}
+}
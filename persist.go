@@ -0,0 +1,91 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// persistInterval limits how often the counters are saved for caught errors.
+// They are always saved straight away for unexpected panics, which may be
+// about to end the process.
+const persistInterval = time.Second
+
+var persist struct {
+	sync.Mutex
+	path  string
+	base  Counters
+	saved time.Time
+}
+
+// SetStatsFile persists the counters returned by Stats in the file at path, so
+// that processes that restart, and crash-looping ones in particular, keep a
+// history of the errors they have handled. The counters of previous runs are
+// loaded from the file if it exists, and are reported by TotalStats. An empty
+// path disables persistence, which is the default.
+func SetStatsFile(path string) error {
+	var base Counters
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &base); err != nil {
+				return err
+			}
+		}
+	}
+	persist.Lock()
+	persist.path = path
+	persist.base = base
+	persist.saved = time.Time{}
+	persist.Unlock()
+	return nil
+}
+
+// TotalStats returns the counters of this process added to those persisted by
+// previous runs with SetStatsFile.
+func TotalStats() Counters {
+	persist.Lock()
+	base := persist.base
+	persist.Unlock()
+	c := Stats()
+	c.Caught += base.Caught
+	c.Unexpected += base.Unexpected
+	c.Dropped += base.Dropped
+	c.NearMisses += base.NearMisses
+	return c
+}
+
+// saveStats writes the total counters to the stats file, if one is set. Unless
+// force is set it does nothing if they were saved recently.
+func saveStats(force bool) {
+	persist.Lock()
+	defer persist.Unlock()
+	if persist.path == "" {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(persist.saved) < persistInterval {
+		return
+	}
+	persist.saved = now
+	c := Stats()
+	c.Caught += persist.base.Caught
+	c.Unexpected += persist.base.Unexpected
+	c.Dropped += persist.base.Dropped
+	c.NearMisses += persist.base.NearMisses
+	b, _ := json.Marshal(c)
+	tmp := persist.path + ".tmp"
+	err := os.WriteFile(tmp, b, 0644)
+	if err == nil {
+		err = os.Rename(tmp, persist.path)
+	}
+	if err != nil {
+		emit(Entry{Severity: SeverityError, Message: "sherlock: could not save stats: " + err.Error()})
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestStatsSavedForBugsOnly(t *testing.T) {
+	sherlock.SetBackend(nil)
+	path := filepath.Join(t.TempDir(), "stats.json")
+	if err := sherlock.SetStatsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer sherlock.SetStatsFile("")
+	exists := func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	errObserved := errors.New("observed")
+	sherlock.Ok(errObserved)
+	if !exists() {
+		t.Fatal("stats not saved for the first diagnostic")
+	}
+	os.Remove(path)
+	sherlock.Ok(errObserved)
+	if exists() {
+		t.Fatal("stats saved again within the save interval")
+	}
+	sherlock.Barrier(func() { panic("bug") })
+	if !exists() {
+		t.Fatal("stats not saved for an unexpected panic")
+	}
+}
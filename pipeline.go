@@ -0,0 +1,70 @@
+package sherlock
+
+// Stage is a single step of a Pipeline.
+type Stage[T any] struct {
+	// Name is embedded in errors raised by the stage, as an Op.
+	Name Op
+	// Run transforms the value passed down the pipeline.
+	Run func(in T) (T, error)
+	// Classifiers map the errors raised by Run, or by Recover, before they are
+	// offered to later stages. They are consulted in order, after the
+	// globally registered classifiers, and apply to this stage alone.
+	Classifiers []Classifier
+	// Recover, if set, is offered the error raised by an earlier stage in
+	// place of running Run. If it returns a nil error the pipeline resumes
+	// with the value it returns.
+	Recover func(in T, err error) (T, error)
+}
+
+// Pipeline runs a value through a sequence of stages, each of which maps its
+// own errors, so that errors are translated between layers as they pass
+// through rather than ad hoc at every call site.
+type Pipeline[T any] []Stage[T]
+
+// Run passes in through each stage in turn and returns the final value. An
+// error raised by a stage, thrown or returned, is mapped by that stage's
+// classifiers, named after it, and offered to the Recover function of each
+// later stage until one recovers it. If none does it is returned. Panics not
+// raised by sherlock are reported as bugs and end the pipeline immediately
+// with a *PanicError.
+func (p Pipeline[T]) Run(in T) (T, error) {
+	v := in
+	var err error
+	for _, s := range p {
+		fn := s.Run
+		if err != nil {
+			if s.Recover == nil {
+				continue
+			}
+			fn = func(in T) (T, error) { return s.Recover(in, err) }
+		}
+		out := v
+		unexpected, serr := guard(func() error {
+			var ferr error
+			out, ferr = fn(v)
+			return ferr
+		})
+		if unexpected {
+			return v, serr
+		}
+		if serr != nil {
+			err = s.mapError(serr)
+			continue
+		}
+		v, err = out, nil
+	}
+	return v, err
+}
+
+func (s *Stage[T]) mapError(err error) error {
+	for _, fn := range s.Classifiers {
+		if sentinel := consult(fn, err); sentinel != nil {
+			err = &classified{err: err, sentinel: sentinel}
+			break
+		}
+	}
+	if s.Name != "" {
+		err = &opError{op: s.Name, err: err}
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package sherlock_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestPipelineClassifierPanics(t *testing.T) {
+	errRaw := errors.New("pipeline test: raw")
+	r := record(t)
+	p := sherlock.Pipeline[int]{{
+		Name:        "parse",
+		Run:         func(int) (int, error) { return 0, errRaw },
+		Classifiers: []sherlock.Classifier{func(error) error { panic("broken rule") }},
+	}}
+	_, err := p.Run(1)
+	if !errors.Is(err, errRaw) {
+		t.Fatalf("Run returned %v, want the raw error", err)
+	}
+	var reported bool
+	for _, e := range r.entries {
+		reported = reported || strings.Contains(e.Message, "broken rule")
+	}
+	if !reported {
+		t.Errorf("panicking classifier not reported: %+v", r.entries)
+	}
+}
+
+func TestPipelineStageMapping(t *testing.T) {
+	errRaw := errors.New("pipeline test: raw")
+	errMapped := errors.New("pipeline test: mapped")
+	var recovered error
+	parsed := false
+	p := sherlock.Pipeline[int]{
+		{
+			Name: "fetch",
+			Run: func(in int) (int, error) {
+				sherlock.Check(errRaw)
+				return in, nil
+			},
+			Classifiers: []sherlock.Classifier{func(err error) error {
+				if errors.Is(err, errRaw) {
+					return errMapped
+				}
+				return nil
+			}},
+		},
+		{
+			Name: "parse",
+			Run: func(in int) (int, error) {
+				parsed = true
+				return in, nil
+			},
+		},
+		{
+			Name: "fallback",
+			Run:  func(in int) (int, error) { return in, nil },
+			Recover: func(in int, err error) (int, error) {
+				recovered = err
+				return 42, nil
+			},
+		},
+		{
+			Name: "double",
+			Run:  func(in int) (int, error) { return in * 2, nil },
+		},
+	}
+	v, err := p.Run(1)
+	if err != nil || v != 84 {
+		t.Fatalf("Run returned %v, %v, want 84 after recovering", v, err)
+	}
+	if parsed {
+		t.Error("stage after the failure ran instead of being skipped")
+	}
+	if !errors.Is(recovered, errMapped) || !errors.Is(recovered, errRaw) {
+		t.Errorf("recovered %v, want the raw error mapped by its stage", recovered)
+	}
+	if ops := sherlock.Ops(recovered); len(ops) != 1 || ops[0] != "fetch" {
+		t.Errorf("recovered error has ops %v, want the failing stage", ops)
+	}
+}
@@ -0,0 +1,85 @@
+package sherlock
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Policy decides what CatchAll and Catch do with a panic that was not raised by
+// sherlock. Diagnostics are written for such panics regardless of the policy.
+type Policy int32
+
+const (
+	// Repanic rethrows the panic. This is the default.
+	Repanic Policy = iota
+	// Assign assigns the panic value if it is an error, and rethrows it
+	// otherwise.
+	Assign
+	// Wrap assigns every panic value, wrapped in a *PanicError.
+	Wrap
+	// Delegate passes every panic value to the handler set with
+	// SetForeignHandler, which decides whether to assign or rethrow it.
+	Delegate
+)
+
+var foreignPolicy int32
+
+// ForeignHandler decides what to do with a panic that was not raised by
+// sherlock, under the Delegate policy. It is given the panic value and the
+// stack it was recovered at, and returns the error to assign, or nil to have
+// the panic rethrown.
+type ForeignHandler func(value interface{}, stack string) error
+
+var foreignHandler atomic.Value // ForeignHandler
+
+// SetForeignHandler sets the handler consulted under the Delegate policy. Until
+// one is set, the Delegate policy rethrows every panic.
+func SetForeignHandler(fn ForeignHandler) {
+	foreignHandler.Store(fn)
+}
+
+// SetForeignPolicy sets the policy CatchAll and Catch apply to panics that were
+// not raised by sherlock.
+func SetForeignPolicy(p Policy) {
+	atomic.StoreInt32(&foreignPolicy, int32(p))
+}
+
+// PanicError is the error assigned by CatchAll for a panic that was not raised
+// by sherlock, under the Wrap policy.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value if it is an error. Runtime panics unwrap to
+// an error that also matches ErrNilAccess, ErrOutOfRange or ErrDivideByZero
+// where appropriate.
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	if err != nil {
+		err = classifyRuntime(err)
+	}
+	return err
+}
+
+// foreign returns the error to assign for a panic that was not raised by
+// sherlock, or nil if the panic should be rethrown.
+func foreign(r interface{}, stack string) error {
+	switch Policy(atomic.LoadInt32(&foreignPolicy)) {
+	case Assign:
+		if err, ok := r.(error); ok {
+			return classifyRuntime(err)
+		}
+	case Wrap:
+		return &PanicError{Value: r, Stack: stack}
+	case Delegate:
+		if fn, _ := foreignHandler.Load().(ForeignHandler); fn != nil {
+			return fn(r, stack)
+		}
+	}
+	return nil
+}
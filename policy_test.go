@@ -0,0 +1,37 @@
+package sherlock_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func divide(a, b int) int {
+	return a / b
+}
+
+func catchDivide(t *testing.T) (caught bool) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			caught = false
+		}
+	}()
+	defer sherlock.Catch(sherlock.ErrDivideByZero, func() { caught = true })
+	divide(1, 0)
+	return false
+}
+
+func TestCatchForeignPolicy(t *testing.T) {
+	sherlock.SetOutput(io.Discard)
+	defer sherlock.SetBackend(nil)
+	if catchDivide(t) {
+		t.Fatal("foreign panic caught under the Repanic policy")
+	}
+	sherlock.SetForeignPolicy(sherlock.Assign)
+	defer sherlock.SetForeignPolicy(sherlock.Repanic)
+	if !catchDivide(t) {
+		t.Fatal("foreign panic not caught under the Assign policy")
+	}
+}
@@ -0,0 +1,123 @@
+package sherlock
+
+import (
+	"sync"
+)
+
+// TaskResult is the outcome of a task run by a Pool. Unexpected is set if the
+// task ended in a panic that was not raised by sherlock, in which case Err is
+// a *PanicError.
+type TaskResult struct {
+	ID         string
+	Err        error
+	Unexpected bool
+}
+
+// Pool runs submitted tasks on a fixed number of worker goroutines, each task
+// within its own recovery boundary, and delivers their outcomes on the Results
+// channel. Results must be received for the pool to make progress.
+type Pool struct {
+	tasks            chan poolTask
+	results          chan TaskResult
+	stop             chan struct{}
+	done             chan struct{}
+	stopOnUnexpected bool
+
+	mu       sync.Mutex
+	closed   bool
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+type poolTask struct {
+	id string
+	fn func() error
+}
+
+// NewPool starts a pool of the given number of workers. If stopOnUnexpected is
+// set, the first task to end in a panic not raised by sherlock stops the pool:
+// tasks that have not started are discarded, and further submissions are
+// refused.
+func NewPool(workers int, stopOnUnexpected bool) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		tasks:            make(chan poolTask),
+		results:          make(chan TaskResult),
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+		stopOnUnexpected: stopOnUnexpected,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	go func() {
+		p.wg.Wait()
+		close(p.results)
+	}()
+	return p
+}
+
+// Submit queues fn to be run as the task identified by id, blocking until a
+// worker is free. It reports false if the pool has been closed or stopped.
+func (p *Pool) Submit(id string, fn func() error) bool {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return false
+	}
+	// Check for a stop first, as a select with a free worker ready as well
+	// would be free to pick the worker.
+	select {
+	case <-p.stop:
+		return false
+	default:
+	}
+	select {
+	case p.tasks <- poolTask{id, fn}:
+		return true
+	case <-p.stop:
+		return false
+	case <-p.done:
+		return false
+	}
+}
+
+// Results returns the channel the outcome of each task is delivered on. It is
+// closed once the pool has been closed or stopped and every running task has
+// finished.
+func (p *Pool) Results() <-chan TaskResult {
+	return p.results
+}
+
+// Close stops the pool accepting tasks, including those of calls to Submit
+// still waiting for a worker. Tasks already submitted still run.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case t := <-p.tasks:
+			unexpected, err := guard(t.fn)
+			if unexpected && p.stopOnUnexpected {
+				p.stopOnce.Do(func() { close(p.stop) })
+			}
+			p.results <- TaskResult{ID: t.id, Err: err, Unexpected: unexpected}
+		case <-p.stop:
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
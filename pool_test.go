@@ -0,0 +1,86 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestPoolCloseWhileSubmitting(t *testing.T) {
+	p := sherlock.NewPool(1, false)
+	block := make(chan struct{})
+	if !p.Submit("busy", func() error { <-block; return nil }) {
+		t.Fatal("first task refused")
+	}
+	refused := make(chan bool)
+	go func() { refused <- !p.Submit("waiting", func() error { return nil }) }()
+	time.Sleep(10 * time.Millisecond)
+	closed := make(chan struct{})
+	go func() { p.Close(); close(closed) }()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind a waiting Submit")
+	}
+	if !<-refused {
+		t.Fatal("waiting task accepted after Close")
+	}
+	close(block)
+	var ids []string
+	for r := range p.Results() {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 1 || ids[0] != "busy" {
+		t.Fatalf("got results for %v", ids)
+	}
+}
+
+func TestPoolResults(t *testing.T) {
+	record(t)
+	errReturned := errors.New("pool test: returned")
+	errThrown := errors.New("pool test: thrown")
+	p := sherlock.NewPool(2, false)
+	go func() {
+		p.Submit("ok", func() error { return nil })
+		p.Submit("returned", func() error { return errReturned })
+		p.Submit("thrown", func() error { sherlock.Check(errThrown); return nil })
+		p.Submit("panicked", func() error { panic("pool test: bug") })
+		p.Close()
+	}()
+	results := make(map[string]sherlock.TaskResult)
+	for r := range p.Results() {
+		results[r.ID] = r
+	}
+	if r := results["ok"]; r.Err != nil || r.Unexpected {
+		t.Errorf("ok: %+v", r)
+	}
+	if r := results["returned"]; !errors.Is(r.Err, errReturned) || r.Unexpected {
+		t.Errorf("returned: %+v", r)
+	}
+	if r := results["thrown"]; !errors.Is(r.Err, errThrown) || r.Unexpected {
+		t.Errorf("thrown: %+v", r)
+	}
+	var pe *sherlock.PanicError
+	if r := results["panicked"]; !errors.As(r.Err, &pe) || !r.Unexpected {
+		t.Errorf("panicked: %+v", r)
+	}
+}
+
+func TestPoolStopOnUnexpected(t *testing.T) {
+	record(t)
+	p := sherlock.NewPool(1, true)
+	go func() {
+		p.Submit("panicked", func() error { panic("pool test: bug") })
+	}()
+	if r := <-p.Results(); !r.Unexpected {
+		t.Fatalf("got %+v, want an unexpected panic", r)
+	}
+	if p.Submit("after", func() error { return nil }) {
+		t.Error("task accepted after an unexpected panic stopped the pool")
+	}
+	for r := range p.Results() {
+		t.Errorf("result %+v after the pool stopped", r)
+	}
+}
@@ -0,0 +1,78 @@
+package preset
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+// Sentinels matched by AWS API errors once AWS has been installed.
+var (
+	ErrThrottled    = errors.New("throttled")
+	ErrAccessDenied = errors.New("access denied")
+	ErrNoSuchEntity = errors.New("no such entity")
+	ErrCondition    = errors.New("condition failed")
+	ErrAWSInternal  = errors.New("aws internal error")
+)
+
+var awsCodes = struct {
+	sync.RWMutex
+	m map[string]error
+}{m: map[string]error{
+	"Throttling":                             ErrThrottled,
+	"ThrottlingException":                    ErrThrottled,
+	"ThrottledException":                     ErrThrottled,
+	"RequestLimitExceeded":                   ErrThrottled,
+	"TooManyRequestsException":               ErrThrottled,
+	"ProvisionedThroughputExceededException": ErrThrottled,
+	"SlowDown":                               ErrThrottled,
+	"AccessDenied":                           ErrAccessDenied,
+	"AccessDeniedException":                  ErrAccessDenied,
+	"UnauthorizedOperation":                  ErrAccessDenied,
+	"NoSuchKey":                              ErrNoSuchEntity,
+	"NoSuchBucket":                           ErrNoSuchEntity,
+	"NotFound":                               ErrNoSuchEntity,
+	"ResourceNotFoundException":              ErrNoSuchEntity,
+	"ConditionalCheckFailedException":        ErrCondition,
+	"PreconditionFailed":                     ErrCondition,
+	"InternalError":                          ErrAWSInternal,
+	"InternalFailure":                        ErrAWSInternal,
+	"ServiceUnavailable":                     ErrAWSInternal,
+}}
+
+var awsOnce sync.Once
+
+// AWS registers a classifier for AWS API errors, matching them by the code
+// returned from their ErrorCode method, as implemented by smithy.APIError,
+// without this package depending on the AWS SDK. Throttling and internal
+// errors are registered as retryable. Further codes can be added with AWSCode.
+func AWS() {
+	awsOnce.Do(func() {
+		sherlock.RegisterSpec(ErrThrottled, Throttled)
+		sherlock.RegisterSpec(ErrAccessDenied, PermissionDenied)
+		sherlock.RegisterSpec(ErrNoSuchEntity, NotFound)
+		sherlock.RegisterSpec(ErrCondition, Conflict)
+		sherlock.RegisterSpec(ErrAWSInternal, Unavailable)
+		sherlock.RegisterRetryable(ErrThrottled, ErrAWSInternal)
+		sherlock.RegisterClassifier(classifyAWS)
+	})
+}
+
+// AWSCode registers sentinel as the error matched by AWS API errors with the
+// given code, replacing any existing mapping for code.
+func AWSCode(code string, sentinel error) {
+	awsCodes.Lock()
+	awsCodes.m[code] = sentinel
+	awsCodes.Unlock()
+}
+
+func classifyAWS(err error) error {
+	var apiErr interface{ ErrorCode() string }
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	awsCodes.RLock()
+	defer awsCodes.RUnlock()
+	return awsCodes.m[apiErr.ErrorCode()]
+}
@@ -0,0 +1,21 @@
+package preset
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+var contextOnce sync.Once
+
+// Context registers the errors of context. context.Canceled is registered as
+// Canceled, and context.DeadlineExceeded as a retryable Timeout. It pairs with
+// sherlock.CheckCtx.
+func Context() {
+	contextOnce.Do(func() {
+		sherlock.RegisterSpec(context.Canceled, Canceled)
+		sherlock.RegisterSpec(context.DeadlineExceeded, Timeout)
+		sherlock.RegisterRetryable(context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,28 @@
+package preset
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+var fsOnce sync.Once
+
+// FS registers the errors of io, io/fs and os. fs.ErrNotExist is registered as
+// NotFound, fs.ErrExist as Conflict, fs.ErrPermission as PermissionDenied,
+// truncated input from io.EOF and io.ErrUnexpectedEOF as BadInput, and
+// os.ErrDeadlineExceeded as a retryable Timeout.
+func FS() {
+	fsOnce.Do(func() {
+		sherlock.RegisterSpec(fs.ErrNotExist, NotFound)
+		sherlock.RegisterSpec(fs.ErrExist, Conflict)
+		sherlock.RegisterSpec(fs.ErrPermission, PermissionDenied)
+		sherlock.RegisterSpec(io.EOF, BadInput)
+		sherlock.RegisterSpec(io.ErrUnexpectedEOF, BadInput)
+		sherlock.RegisterSpec(os.ErrDeadlineExceeded, Timeout)
+		sherlock.RegisterRetryable(os.ErrDeadlineExceeded)
+	})
+}
@@ -0,0 +1,89 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+// ErrBadInput is matched by every error classified by Input, and each of the
+// more specific sentinels also matches it.
+var ErrBadInput = errors.New("bad input")
+
+// Sentinels matched by parse errors once Input has been installed.
+var (
+	ErrMalformed = inputError("malformed input")
+	ErrWrongType = inputError("wrong type")
+	ErrBadNumber = inputError("bad number")
+)
+
+type inputError string
+
+func (e inputError) Error() string {
+	return string(e)
+}
+
+func (e inputError) Is(target error) bool {
+	return target == ErrBadInput
+}
+
+var inputOnce sync.Once
+
+// Input registers a classifier for the parse errors of encoding/json and
+// strconv. *json.SyntaxError matches ErrMalformed, *json.UnmarshalTypeError
+// matches ErrWrongType, and *strconv.NumError matches ErrBadNumber, all of
+// which match ErrBadInput and are registered as BadInput. InputDetails returns
+// the details of where the input went wrong.
+func Input() {
+	inputOnce.Do(func() {
+		for _, err := range []error{ErrBadInput, ErrMalformed, ErrWrongType, ErrBadNumber} {
+			sherlock.RegisterSpec(err, BadInput)
+		}
+		sherlock.RegisterClassifier(classifyInput)
+	})
+}
+
+func classifyInput(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return ErrMalformed
+	case errors.As(err, &typeErr):
+		return ErrWrongType
+	case errors.As(err, &numErr):
+		return ErrBadNumber
+	}
+	return nil
+}
+
+// InputDetails returns the details of a parse error: the offset of a JSON
+// syntax error; the offset, field, value and expected type of a JSON type
+// error; or the function and input of a strconv error. It returns nil for any
+// other error.
+func InputDetails(err error) sherlock.Fields {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return sherlock.Fields{"offset": syntaxErr.Offset}
+	case errors.As(err, &typeErr):
+		f := sherlock.Fields{
+			"offset": typeErr.Offset,
+			"field":  typeErr.Field,
+			"value":  typeErr.Value,
+		}
+		if typeErr.Type != nil {
+			f["type"] = typeErr.Type.String()
+		}
+		return f
+	case errors.As(err, &numErr):
+		return sherlock.Fields{"func": numErr.Func, "num": numErr.Num}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package preset
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+// Sentinels matched by network errors once Net has been installed.
+var (
+	ErrConnRefused  = errors.New("connection refused")
+	ErrConnReset    = errors.New("connection reset")
+	ErrNetTimeout   = errors.New("network timeout")
+	ErrHostNotFound = errors.New("host not found")
+	ErrResolve      = errors.New("name resolution failed")
+)
+
+var netOnce sync.Once
+
+// Net registers a classifier for network errors, which are created
+// dynamically and so cannot be registered directly. Thrown errors are matched
+// to ErrNetTimeout if they report a timeout, to ErrHostNotFound or ErrResolve
+// for DNS failures, and to ErrConnRefused or ErrConnReset by their underlying
+// system error. All but ErrHostNotFound are registered as retryable.
+func Net() {
+	netOnce.Do(func() {
+		sherlock.RegisterSpec(ErrConnRefused, Unavailable)
+		sherlock.RegisterSpec(ErrConnReset, Unavailable)
+		sherlock.RegisterSpec(ErrNetTimeout, Timeout)
+		sherlock.RegisterSpec(ErrHostNotFound, Unavailable)
+		sherlock.RegisterSpec(ErrResolve, Unavailable)
+		sherlock.RegisterRetryable(ErrConnRefused, ErrConnReset, ErrNetTimeout, ErrResolve)
+		sherlock.RegisterClassifier(classifyNet)
+	})
+}
+
+func classifyNet(err error) error {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return ErrHostNotFound
+	case dnsErr != nil:
+		return ErrResolve
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrNetTimeout
+	}
+	return classifyErrno(err)
+}
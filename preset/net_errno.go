@@ -0,0 +1,19 @@
+//go:build !plan9
+
+package preset
+
+import (
+	"errors"
+	"syscall"
+)
+
+// classifyErrno matches err by its underlying system error.
+func classifyErrno(err error) error {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrConnRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ErrConnReset
+	}
+	return nil
+}
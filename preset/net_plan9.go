@@ -0,0 +1,17 @@
+package preset
+
+import "strings"
+
+// classifyErrno matches err by its underlying system error. Plan 9 reports
+// system errors as strings rather than numbers, so they are matched by their
+// text.
+func classifyErrno(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "connection refused"):
+		return ErrConnRefused
+	case strings.Contains(msg, "connection reset"):
+		return ErrConnReset
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !plan9
+
+package preset
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyNet(t *testing.T) {
+	for err, want := range map[error]error{
+		&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}: ErrConnRefused,
+		&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}:      ErrConnReset,
+		&net.DNSError{Name: "example.invalid", IsNotFound: true}:                           ErrHostNotFound,
+		&net.DNSError{Name: "example.com", IsTemporary: true}:                              ErrResolve,
+		os.ErrNotExist: nil,
+	} {
+		if got := classifyNet(err); got != want {
+			t.Errorf("classifyNet(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package preset
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+// Sentinels matched by PostgreSQL errors once Postgres has been installed.
+var (
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrSerialization       = errors.New("serialization failure")
+	ErrDeadlock            = errors.New("deadlock detected")
+	ErrPGConnection        = errors.New("database connection failed")
+)
+
+// pgCodes maps SQLSTATE codes, or the two-character classes they belong to, to
+// the sentinels they match.
+var pgCodes = struct {
+	sync.RWMutex
+	m map[string]error
+}{m: map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"40001": ErrSerialization,
+	"40P01": ErrDeadlock,
+	"08":    ErrPGConnection,
+	"57P01": ErrPGConnection, // admin_shutdown
+}}
+
+var postgresOnce sync.Once
+
+// Postgres registers a classifier for PostgreSQL errors, matching them by the
+// SQLSTATE returned from their SQLState method, as implemented by
+// *pgconn.PgError and *pq.Error, without this package depending on either
+// driver. Unique and foreign key violations, serialization failures and
+// deadlocks are registered as Conflict, and lost connections as Unavailable.
+// Serialization failures, deadlocks and lost connections are also registered
+// as retryable. Further codes, or whole classes by their first two characters,
+// can be added with PostgresCode.
+func Postgres() {
+	postgresOnce.Do(func() {
+		sherlock.RegisterSpec(ErrUniqueViolation, Conflict)
+		sherlock.RegisterSpec(ErrForeignKeyViolation, Conflict)
+		sherlock.RegisterSpec(ErrSerialization, Conflict)
+		sherlock.RegisterSpec(ErrDeadlock, Conflict)
+		sherlock.RegisterSpec(ErrPGConnection, Unavailable)
+		sherlock.RegisterRetryable(ErrSerialization, ErrDeadlock, ErrPGConnection)
+		sherlock.RegisterClassifier(classifyPostgres)
+	})
+}
+
+// PostgresCode registers sentinel as the error matched by PostgreSQL errors
+// with the given SQLSTATE, or with any SQLSTATE in the given two-character
+// class, replacing any existing mapping for code. A mapping for a full code
+// takes precedence over one for its class.
+func PostgresCode(code string, sentinel error) {
+	pgCodes.Lock()
+	pgCodes.m[code] = sentinel
+	pgCodes.Unlock()
+}
+
+func classifyPostgres(err error) error {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+	state := pgErr.SQLState()
+	pgCodes.RLock()
+	defer pgCodes.RUnlock()
+	if sentinel, ok := pgCodes.m[state]; ok {
+		return sentinel
+	}
+	if len(state) == 5 {
+		return pgCodes.m[state[:2]]
+	}
+	return nil
+}
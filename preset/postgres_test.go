@@ -0,0 +1,27 @@
+package preset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// pgError stands in for *pgconn.PgError and *pq.Error.
+type pgError struct{ code string }
+
+func (e *pgError) Error() string    { return "pg error " + e.code }
+func (e *pgError) SQLState() string { return e.code }
+
+func TestClassifyPostgres(t *testing.T) {
+	for err, want := range map[error]error{
+		&pgError{"23505"}: ErrUniqueViolation,
+		fmt.Errorf("insert: %w", &pgError{"40001"}): ErrSerialization,
+		&pgError{"08006"}:          ErrPGConnection,
+		&pgError{"42P01"}:          nil,
+		errors.New("not postgres"): nil,
+	} {
+		if got := classifyPostgres(err); got != want {
+			t.Errorf("classifyPostgres(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
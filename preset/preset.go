@@ -0,0 +1,64 @@
+/*
+Package preset registers sensible defaults for the errors of commonly used
+standard library packages, so that every project does not have to register them
+by hand. Each function installs the registrations for one area. It may be
+called more than once, and from several goroutines, but only the first call
+registers anything, so that classifiers are never registered twice. Calling it
+again after a sherlock.Restore to a snapshot taken before the first call does
+not reinstate the registrations.
+
+	func init() {
+		preset.SQL()
+	}
+*/
+package preset
+
+import (
+	"net/http"
+
+	"github.com/alankm/sherlock"
+)
+
+// The canonical specs that presets register errors with.
+var (
+	NotFound = sherlock.Spec{
+		Code:       "not_found",
+		HTTPStatus: http.StatusNotFound,
+		Message:    "not found",
+	}
+	Conflict = sherlock.Spec{
+		Code:       "conflict",
+		HTTPStatus: http.StatusConflict,
+		Message:    "conflict",
+	}
+	Unavailable = sherlock.Spec{
+		Code:       "unavailable",
+		HTTPStatus: http.StatusServiceUnavailable,
+		Message:    "service unavailable",
+	}
+	BadInput = sherlock.Spec{
+		Code:       "bad_input",
+		HTTPStatus: http.StatusBadRequest,
+		Message:    "bad input",
+	}
+	PermissionDenied = sherlock.Spec{
+		Code:       "permission_denied",
+		HTTPStatus: http.StatusForbidden,
+		Message:    "permission denied",
+	}
+	Canceled = sherlock.Spec{
+		Code:       "canceled",
+		HTTPStatus: 499, // client closed request
+		Message:    "canceled",
+	}
+	Throttled = sherlock.Spec{
+		Code:       "throttled",
+		HTTPStatus: http.StatusTooManyRequests,
+		Message:    "too many requests",
+	}
+	Timeout = sherlock.Spec{
+		Code:       "timeout",
+		HTTPStatus: http.StatusGatewayTimeout,
+		Message:    "timed out",
+	}
+)
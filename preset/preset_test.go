@@ -0,0 +1,34 @@
+package preset
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestInstallOnce(t *testing.T) {
+	install := func() {
+		AWS()
+		Context()
+		FS()
+		Input()
+		Net()
+		Postgres()
+		SQL()
+	}
+	install()
+	before := sherlock.MemoryStats()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			install()
+		}()
+	}
+	wg.Wait()
+	if after := sherlock.MemoryStats(); after.Classifiers != before.Classifiers {
+		t.Fatalf("classifiers grew from %d to %d", before.Classifiers, after.Classifiers)
+	}
+}
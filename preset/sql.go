@@ -0,0 +1,25 @@
+package preset
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/alankm/sherlock"
+)
+
+var sqlOnce sync.Once
+
+// SQL registers the errors of database/sql. sql.ErrNoRows is registered as
+// NotFound, sql.ErrTxDone as Conflict, and sql.ErrConnDone and
+// driver.ErrBadConn as Unavailable. Lost connections are also registered as
+// retryable.
+func SQL() {
+	sqlOnce.Do(func() {
+		sherlock.RegisterSpec(sql.ErrNoRows, NotFound)
+		sherlock.RegisterSpec(sql.ErrTxDone, Conflict)
+		sherlock.RegisterSpec(sql.ErrConnDone, Unavailable)
+		sherlock.RegisterSpec(driver.ErrBadConn, Unavailable)
+		sherlock.RegisterRetryable(sql.ErrConnDone, driver.ErrBadConn)
+	})
+}
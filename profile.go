@@ -0,0 +1,99 @@
+package sherlock
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Profile bundles the settings appropriate to an environment, so that the same
+// binary can behave appropriately in development, staging and production by
+// selecting a profile rather than through conditionals around each setting.
+type Profile struct {
+	// ForeignPolicy is passed to SetForeignPolicy.
+	ForeignPolicy Policy
+	// Info is passed to SetInfo.
+	Info bool
+	// Deterministic is passed to SetDeterministic.
+	Deterministic bool
+	// Strict is passed to SetStrict.
+	Strict bool
+	// SourceLines is passed to SetSourceLines.
+	SourceLines int
+	// Sampler, if set, is copied and installed with SetSampler. Otherwise
+	// every diagnostic is written.
+	Sampler *Sampler
+	// Backend, if set, is installed with SetBackend. Otherwise the backend
+	// is left as it is.
+	Backend Backend
+}
+
+var profiles = struct {
+	sync.Mutex
+	m map[string]Profile
+}{m: map[string]Profile{
+	"dev": {
+		ForeignPolicy: Repanic,
+		Info:          true,
+		Strict:        true,
+		SourceLines:   2,
+	},
+	"staging": {
+		ForeignPolicy: Wrap,
+		Info:          true,
+		Sampler:       &Sampler{First: 100, Rate: 0.1, Period: time.Minute},
+	},
+	"prod": {
+		ForeignPolicy: Wrap,
+		Sampler:       &Sampler{First: 10, Rate: 1, Period: time.Minute, Target: 100},
+	},
+}}
+
+func init() {
+	if name := os.Getenv("SHERLOCK_PROFILE"); name != "" {
+		if err := UseProfile(name); err != nil {
+			emit(Entry{Severity: SeverityWarning, Message: err.Error()})
+		}
+	}
+}
+
+// RegisterProfile registers p under name, replacing any profile already
+// registered under it. The profiles "dev", "staging" and "prod" are
+// registered by default.
+func RegisterProfile(name string, p Profile) {
+	profiles.Lock()
+	profiles.m[name] = p
+	profiles.Unlock()
+}
+
+// UseProfile applies the settings of the profile registered under name. The
+// profile named by the SHERLOCK_PROFILE environment variable, if set, is
+// applied when the program starts.
+func UseProfile(name string) error {
+	profiles.Lock()
+	p, ok := profiles.m[name]
+	profiles.Unlock()
+	if !ok {
+		return fmt.Errorf("sherlock: unknown profile %q", name)
+	}
+	SetForeignPolicy(p.ForeignPolicy)
+	SetInfo(p.Info)
+	SetDeterministic(p.Deterministic)
+	SetStrict(p.Strict)
+	SetSourceLines(p.SourceLines)
+	if p.Sampler != nil {
+		SetSampler(&Sampler{
+			First:  p.Sampler.First,
+			Rate:   p.Sampler.Rate,
+			Period: p.Sampler.Period,
+			Target: p.Sampler.Target,
+		})
+	} else {
+		SetSampler(nil)
+	}
+	if p.Backend != nil {
+		SetBackend(p.Backend)
+	}
+	return nil
+}
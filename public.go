@@ -0,0 +1,27 @@
+package sherlock
+
+// DefaultPublicMessage is returned by PublicMessage for errors that have no
+// public message, so that internal details are never shown by default.
+const DefaultPublicMessage = "internal error"
+
+var publicMessages table[string]
+
+// RegisterPublicMessage registers msg as the message that is safe to show to
+// users for err. The internal message, err.Error(), is still the one written
+// to diagnostics.
+func RegisterPublicMessage(err error, msg string) {
+	publicMessages.set(err, msg)
+}
+
+// PublicMessage returns the message that is safe to show to users for err. A
+// Coded error in err's chain with a Public message takes precedence over any
+// registered message. Otherwise DefaultPublicMessage is returned.
+func PublicMessage(err error) string {
+	if c := coded(err); c != nil && c.Public != "" {
+		return c.Public
+	}
+	if msg, ok := publicMessages.lookup(err); ok {
+		return msg
+	}
+	return DefaultPublicMessage
+}
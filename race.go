@@ -0,0 +1,53 @@
+package sherlock
+
+import (
+	"context"
+	"errors"
+)
+
+// Race runs fns concurrently, each within a recovery boundary, and returns as
+// soon as one of them succeeds, cancelling the context passed to the rest. A
+// function ending in a panic not raised by sherlock, or with an error of
+// SeverityFatal, also ends the race at once, and its error is returned. If
+// every function fails otherwise, their errors are returned joined together.
+// Race waits for every function to return before it does.
+func Race(ctx context.Context, fns ...func(ctx context.Context) error) error {
+	type result struct {
+		unexpected bool
+		err        error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, len(fns))
+	for _, fn := range fns {
+		fn := fn
+		go func() {
+			unexpected, err := guard(func() error { return fn(ctx) })
+			results <- result{unexpected, err}
+		}()
+	}
+	var errs []error
+	var final error
+	done := false
+	for range fns {
+		r := <-results
+		switch {
+		case done:
+		case r.err == nil:
+			done = true
+			cancel()
+		case r.unexpected || SeverityOf(r.err).atLeast(SeverityFatal):
+			final, done = r.err, true
+			cancel()
+		default:
+			errs = append(errs, r.err)
+		}
+	}
+	switch {
+	case done:
+		return final
+	case len(errs) == 1:
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,63 @@
+package sherlock
+
+import (
+	"sync"
+)
+
+// A Redactor rewrites text before sherlock writes it anywhere, so that secrets
+// such as tokens in URLs or passwords in DSNs never reach logs or reports.
+type Redactor func(string) string
+
+var redactor struct {
+	sync.Mutex
+	fn Redactor
+}
+
+// SetRedactor installs fn to be applied to every error message, stack and
+// source snippet before it is written to a backend, a crash report, or a
+// notification, and to the messages of the errors in the audit trail, the
+// events delivered by Subscribe and the counts returned by TopErrors. A nil fn
+// disables redaction, which is the default.
+func SetRedactor(fn Redactor) {
+	redactor.Lock()
+	redactor.fn = fn
+	redactor.Unlock()
+}
+
+func redact(s string) string {
+	redactor.Lock()
+	fn := redactor.fn
+	redactor.Unlock()
+	if fn == nil || s == "" {
+		return s
+	}
+	return fn(s)
+}
+
+// redactedError presents the message of err as redacted, while unwrapping to
+// err so that it still matches whatever err matches.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns err with its message redacted, or err itself if
+// redaction leaves the message unchanged.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if r := redact(msg); r != msg {
+		return &redactedError{err: err, msg: r}
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package sherlock_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestRedactedRecords(t *testing.T) {
+	record(t)
+	sherlock.SetRedactor(func(s string) string { return strings.ReplaceAll(s, "hunter2", "***") })
+	defer sherlock.SetRedactor(nil)
+	sherlock.SetAuditSize(10)
+	defer sherlock.SetAuditSize(0)
+	ch := sherlock.Subscribe(nil)
+	defer sherlock.Unsubscribe(ch)
+
+	errSecret := errors.New("redact test: password hunter2 rejected")
+	sherlock.Ok(errSecret)
+
+	log := sherlock.AuditLog()
+	if len(log) != 1 {
+		t.Fatalf("got audit log %+v", log)
+	}
+	if msg := log[0].Err.Error(); strings.Contains(msg, "hunter2") {
+		t.Errorf("audit trail holds %q", msg)
+	}
+	if !errors.Is(log[0].Err, errSecret) {
+		t.Error("redacted audit error no longer matches the original")
+	}
+	select {
+	case e := <-ch:
+		if strings.Contains(e.Err.Error(), "hunter2") {
+			t.Errorf("event holds %q", e.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event delivered")
+	}
+	found := false
+	for _, c := range sherlock.TopErrors(-1, time.Minute) {
+		if strings.Contains(c.Message, "hunter2") {
+			t.Errorf("TopErrors returned %q", c.Message)
+		}
+		found = found || c.Message == "redact test: password *** rejected"
+	}
+	if !found {
+		t.Error("TopErrors lacks the redacted message")
+	}
+}
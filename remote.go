@@ -0,0 +1,31 @@
+package sherlock
+
+// remote carries the severity and retryability of a decoded error, which
+// SeverityOf and IsRetryable report in preference to any registered for it,
+// as they are what the error's sender determined.
+type remote struct {
+	err       error // the error registered with the envelope's code, if any
+	severity  Severity
+	retryable bool
+}
+
+func (r *remote) Error() string {
+	if r.err == nil {
+		return "remote error"
+	}
+	return r.err.Error()
+}
+
+func (r *remote) Unwrap() error {
+	return r.err
+}
+
+// remoteOf returns the first *remote in err's chain, or nil if there is none.
+func remoteOf(err error) *remote {
+	var r *remote
+	walk(err, func(e error) bool {
+		r, _ = e.(*remote)
+		return r != nil
+	})
+	return r
+}
@@ -0,0 +1,40 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import "time"
+
+// reportingState holds the settings of the reporting left out of the reduced
+// build: the watchdog, the notifier, crash reports and crash loop detection.
+type reportingState struct {
+	watchdog          *Watchdog
+	notifier          *Notifier
+	crashDir          string
+	crashLoop         int
+	crashLoopWindow   time.Duration
+	crashLoopCooldown time.Duration
+}
+
+func snapshotReporting() reportingState {
+	var s reportingState
+	watchdog.Lock()
+	s.watchdog = watchdog.w
+	watchdog.Unlock()
+	notifier.Lock()
+	s.notifier = notifier.n
+	notifier.Unlock()
+	crash.Lock()
+	s.crashDir = crash.dir
+	crash.Unlock()
+	crashLoop.Lock()
+	s.crashLoop, s.crashLoopWindow, s.crashLoopCooldown = crashLoop.n, crashLoop.window, crashLoop.cooldown
+	crashLoop.Unlock()
+	return s
+}
+
+func restoreReporting(s reportingState) {
+	SetWatchdog(s.watchdog)
+	SetNotifier(s.notifier)
+	SetCrashDir(s.crashDir)
+	SetCrashLoop(s.crashLoop, s.crashLoopWindow, s.crashLoopCooldown)
+}
@@ -0,0 +1,75 @@
+package sherlock
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff describes how Retry spaces out its attempts. The zero value makes
+// three attempts, 100ms and then 200ms apart.
+type Backoff struct {
+	// Attempts is the maximum number of times fn is run, defaulting to 3.
+	Attempts int
+	// Initial is the delay before the second attempt, defaulting to 100ms.
+	Initial time.Duration
+	// Max caps the delay between attempts. Zero means no cap.
+	Max time.Duration
+	// Multiplier is the factor the delay grows by after each attempt,
+	// defaulting to 2.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomised, to keep many clients from retrying in lockstep.
+	Jitter float64
+}
+
+// delay returns the delay before attempt n, counting from 1 for the second.
+func (b Backoff) delay(n int) time.Duration {
+	d := float64(b.Initial)
+	if d == 0 {
+		d = float64(100 * time.Millisecond)
+	}
+	m := b.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	d *= math.Pow(m, float64(n-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d -= d * b.Jitter * rand.Float64()
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64 // without a cap, delays grow past what a Duration holds
+	}
+	return time.Duration(d)
+}
+
+// Retry runs fn until it succeeds, fails with an error that is not retryable
+// according to IsRetryable, or has been attempted as many times as b allows,
+// waiting between attempts as b describes. Errors thrown by fn are caught as
+// well as returned ones, and the last error is returned after passing through
+// the registered classifiers.
+func Retry(b Backoff, fn func() error) error {
+	attempts := b.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	var err error
+	for n := 0; n < attempts; n++ {
+		if n > 0 {
+			time.Sleep(b.delay(n))
+		}
+		err = attempt(fn)
+		if err == nil || !IsRetryable(err) {
+			break
+		}
+	}
+	return err
+}
+
+func attempt(fn func() error) (err error) {
+	defer CatchAll(&err)
+	return classify(fn())
+}
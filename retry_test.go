@@ -0,0 +1,26 @@
+package sherlock
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	for _, tt := range []struct {
+		b    Backoff
+		n    int
+		want time.Duration
+	}{
+		{Backoff{}, 1, 100 * time.Millisecond},
+		{Backoff{}, 3, 400 * time.Millisecond},
+		{Backoff{Initial: time.Second, Multiplier: 3}, 3, 9 * time.Second},
+		{Backoff{Max: time.Second}, 10, time.Second},
+		{Backoff{}, 40, math.MaxInt64},
+		{Backoff{}, 1 << 20, math.MaxInt64},
+	} {
+		if got := tt.b.delay(tt.n); got != tt.want {
+			t.Errorf("%+v.delay(%v) = %v, want %v", tt.b, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package sherlock
+
+var retryable table[bool]
+
+// RegisterRetryable marks each of errs as retryable, meaning that an operation
+// failing with one of them may succeed if it is attempted again.
+func RegisterRetryable(errs ...error) {
+	for _, err := range errs {
+		retryable.set(err, true)
+	}
+}
+
+// IsRetryable reports whether an operation that failed with err should be
+// retried. This is true if err was registered as retryable, or if any error in
+// its chain reports itself as temporary. An error decoded by Decode is retryable
+// if it was encoded as retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if r := remoteOf(err); r != nil {
+		return r.retryable
+	}
+	if ok, _ := retryable.lookup(err); ok {
+		return true
+	}
+	return walk(err, func(e error) bool {
+		t, ok := e.(interface{ Temporary() bool })
+		return ok && t.Temporary()
+	})
+}
@@ -0,0 +1,66 @@
+package sherlock
+
+import (
+	"errors"
+	"sync"
+)
+
+// Rollback collects compensating actions for the steps of an operation, to be
+// run in reverse order if the operation fails, as in a saga. The zero value is
+// ready to use.
+//
+//	func Transfer(from, to *Account, n int) (err error) {
+//		var rb sherlock.Rollback
+//		defer rb.Close(&err)
+//		defer sherlock.CatchAll(&err)
+//		sherlock.Check(from.Withdraw(n))
+//		rb.Defer(func() error { return from.Deposit(n) })
+//		sherlock.Check(to.Deposit(n))
+//		return nil
+//	}
+type Rollback struct {
+	mu   sync.Mutex
+	undo []func() error
+}
+
+// Defer registers undo to be run if the operation fails.
+func (rb *Rollback) Defer(undo func() error) {
+	rb.mu.Lock()
+	rb.undo = append(rb.undo, undo)
+	rb.mu.Unlock()
+}
+
+// Close must be deferred. If the operation is panicking, or err holds an error,
+// Close runs the registered compensations in reverse order before the panic or
+// error propagates. Errors from the compensations are joined into err, or
+// reported as warnings if the operation is panicking. On success the
+// compensations are discarded.
+func (rb *Rollback) Close(err *error) {
+	r := recover()
+	rb.mu.Lock()
+	undo := rb.undo
+	rb.undo = nil
+	rb.mu.Unlock()
+	if r == nil && (err == nil || *err == nil) {
+		return
+	}
+	var errs []error
+	for i := len(undo) - 1; i >= 0; i-- {
+		if uerr := undo[i](); uerr != nil {
+			errs = append(errs, uerr)
+		}
+	}
+	if r != nil {
+		for _, uerr := range errs {
+			diagnose(Entry{
+				Severity: SeverityWarning,
+				Message:  "sherlock: rollback: " + uerr.Error(),
+				Hint:     Hint(uerr),
+			})
+		}
+		panic(r)
+	}
+	if len(errs) > 0 {
+		*err = errors.Join(append([]error{*err}, errs...)...)
+	}
+}
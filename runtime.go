@@ -0,0 +1,64 @@
+package sherlock
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+)
+
+// Sentinels for common runtime panics. When CatchAll assigns a runtime panic
+// under the Assign or Wrap policies, the assigned error matches the relevant
+// sentinel with errors.Is, while still unwrapping to the original
+// runtime.Error.
+var (
+	ErrNilAccess    = errors.New("nil access")
+	ErrOutOfRange   = errors.New("out of range")
+	ErrDivideByZero = errors.New("divide by zero")
+)
+
+var runtimeErrors = []struct {
+	substr   string
+	sentinel error
+}{
+	{"nil pointer dereference", ErrNilAccess},
+	{"assignment to entry in nil map", ErrNilAccess},
+	{"index out of range", ErrOutOfRange},
+	{"slice bounds out of range", ErrOutOfRange},
+	{"integer divide by zero", ErrDivideByZero},
+}
+
+type runtimeError struct {
+	err      runtime.Error
+	sentinel error
+}
+
+func (e *runtimeError) Error() string {
+	return e.err.Error()
+}
+
+// RuntimeError marks the wrapper as a runtime.Error, like the error it wraps.
+func (e *runtimeError) RuntimeError() {}
+
+func (e *runtimeError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *runtimeError) Unwrap() error {
+	return e.err
+}
+
+// classifyRuntime returns err wrapped so that it matches the sentinel for its
+// kind of runtime panic. Unrecognised errors are returned unchanged.
+func classifyRuntime(err error) error {
+	re, ok := err.(runtime.Error)
+	if !ok {
+		return err
+	}
+	msg := re.Error()
+	for _, r := range runtimeErrors {
+		if strings.Contains(msg, r.substr) {
+			return &runtimeError{err: re, sentinel: r.sentinel}
+		}
+	}
+	return err
+}
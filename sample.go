@@ -0,0 +1,129 @@
+package sherlock
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler limits the diagnostics written during a burst of errors. The first
+// First diagnostics of each Period are always written, after which only a
+// random Rate fraction of them are. A zero Period never resets the count. The
+// diagnostics of unexpected panics, and of the unexpected errors that end Main,
+// are never sampled out.
+//
+// If Target is set, along with a Period, the rate adapts to keep the volume of
+// diagnostics at roughly Target per Period: at the end of each period the rate
+// for the next is set to what would have written Target diagnostics in the
+// one just ended, so that sampling tightens as error rates climb and relaxes
+// as they fall. Rate is then only the rate for the first period.
+type Sampler struct {
+	First  int
+	Rate   float64
+	Period time.Duration
+	Target int
+
+	mu       sync.Mutex
+	start    time.Time
+	n        int
+	adaptive float64
+	adapted  bool
+}
+
+func (s *Sampler) sample(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Period > 0 && now.Sub(s.start) >= s.Period {
+		if s.Target > 0 && !s.start.IsZero() {
+			s.adapt()
+		}
+		s.start = now
+		s.n = 0
+	}
+	s.n++
+	if s.n <= s.First {
+		return true
+	}
+	rate := s.Rate
+	if s.adapted {
+		rate = s.adaptive
+	}
+	return rand.Float64() < rate
+}
+
+// adapt sets the rate for the next period from the number of diagnostics seen
+// in the period just ended.
+func (s *Sampler) adapt() {
+	s.adapted = true
+	sampled := s.n - s.First
+	budget := s.Target - s.First
+	switch {
+	case sampled <= budget:
+		s.adaptive = 1
+	case budget <= 0:
+		s.adaptive = 0
+	default:
+		s.adaptive = float64(budget) / float64(sampled)
+	}
+}
+
+var sampler struct {
+	sync.Mutex
+	s *Sampler
+}
+
+// SetSampler installs s to sample diagnostics. A nil s writes every
+// diagnostic, which is the default. Sampling never affects Stats.
+func SetSampler(s *Sampler) {
+	sampler.Lock()
+	sampler.s = s
+	sampler.Unlock()
+}
+
+// Counters holds the totals returned by Stats.
+type Counters struct {
+	Caught     uint64 // errors caught by CatchAll
+	Unexpected uint64 // panics considered to be bugs
+	Dropped    uint64 // diagnostics suppressed by sampling
+	NearMisses uint64 // errors matched by SetFuzzyMatch
+}
+
+var counters struct {
+	caught, unexpected, dropped, nearMisses uint64
+}
+
+// Stats returns the number of errors sherlock has handled since the process
+// started.
+func Stats() Counters {
+	return Counters{
+		Caught:     atomic.LoadUint64(&counters.caught),
+		Unexpected: atomic.LoadUint64(&counters.unexpected),
+		Dropped:    atomic.LoadUint64(&counters.dropped),
+		NearMisses: atomic.LoadUint64(&counters.nearMisses),
+	}
+}
+
+// diagnose counts the diagnostic of an error that was handled as expected, such
+// as one caught by CatchAll, and writes it unless it is sampled out.
+func diagnose(e Entry) {
+	atomic.AddUint64(&counters.caught, 1)
+	sampler.Lock()
+	s := sampler.s
+	sampler.Unlock()
+	if s != nil && !s.sample(time.Now()) {
+		atomic.AddUint64(&counters.dropped, 1)
+		return
+	}
+	saveStats(false)
+	emit(e)
+}
+
+// diagnoseBug counts and writes the diagnostic for an unexpected panic, which
+// is never sampled out, and saves the stats straight away, as the process may
+// be about to end.
+func diagnoseBug(e Entry) {
+	atomic.AddUint64(&counters.unexpected, 1)
+	saveStats(true)
+	emit(e)
+}
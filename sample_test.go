@@ -0,0 +1,24 @@
+package sherlock_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestSamplerKeepsBugs(t *testing.T) {
+	var b bytes.Buffer
+	sherlock.SetOutput(&b)
+	defer sherlock.SetBackend(nil)
+	sherlock.SetSampler(&sherlock.Sampler{Period: time.Hour})
+	defer sherlock.SetSampler(nil)
+	sherlock.Ok(errors.New("sampled out"))
+	sherlock.Barrier(func() { panic("sampled bug") })
+	if out := b.String(); strings.Contains(out, "sampled out") || !strings.Contains(out, "sampled bug") {
+		t.Fatalf("got diagnostics %q", out)
+	}
+}
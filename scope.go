@@ -0,0 +1,71 @@
+package sherlock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Scope tracks the resources acquired by a function so that they are all
+// closed when it exits, whether it returns or a sherlock panic unwinds
+// through it.
+//
+//	func Copy(dst, src string) (err error) {
+//		scope := sherlock.NewScope()
+//		defer scope.Close(&err)
+//		defer sherlock.CatchAll(&err)
+//		in, err := os.Open(src)
+//		sherlock.Check(err)
+//		scope.Track(in)
+//		...
+//	}
+type Scope struct {
+	mu      sync.Mutex
+	closers []io.Closer
+}
+
+// NewScope returns an empty Scope.
+func NewScope() *Scope {
+	return new(Scope)
+}
+
+// Track registers c to be closed when the scope is closed.
+func (s *Scope) Track(c io.Closer) {
+	s.mu.Lock()
+	s.closers = append(s.closers, c)
+	s.mu.Unlock()
+}
+
+// Close must be deferred. It closes the tracked resources in the reverse of
+// the order they were tracked in, and merges their errors into err after
+// passing them through the registered classifiers. If err already holds an
+// error the errors are joined, so that an earlier failure is never
+// overwritten. If err is nil the errors are thrown instead, for a
+// CatchAll deferred before Close to catch.
+func (s *Scope) Close(err *error) {
+	defer traceRegion(context.Background(), "Scope.Close").End()
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if cerr := closers[i].Close(); cerr != nil {
+			errs = append(errs, classify(cerr))
+		}
+	}
+	switch {
+	case len(errs) == 0:
+	case err == nil && len(errs) == 1:
+		Throw(errs[0])
+	case err == nil:
+		Throw(errors.Join(errs...))
+	case *err == nil && len(errs) == 1:
+		*err = errs[0]
+	case *err == nil:
+		*err = errors.Join(errs...)
+	default:
+		*err = errors.Join(append([]error{*err}, errs...)...)
+	}
+}
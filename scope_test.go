@@ -0,0 +1,98 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+	"github.com/alankm/sherlock/internal/crosspkg"
+)
+
+const crossPackage = "github.com/alankm/sherlock/internal/crosspkg"
+
+var errScoped = errors.New("scoped")
+
+// thrown runs fn and returns what it panicked with.
+func thrown(fn func()) (r interface{}) {
+	defer func() {
+		r = recover()
+	}()
+	fn()
+	return nil
+}
+
+func TestPackageIsThrower(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"Check": func() { crosspkg.Check(errScoped) },
+		"Bind":  func() { crosspkg.Throw(errScoped) },
+	} {
+		info, ok := sherlock.InfoOf(thrown(fn))
+		if !ok {
+			t.Fatalf("%v: expected a sherlock panic", name)
+		}
+		if info.Package != crossPackage {
+			t.Errorf("%v: got package %q, want %q", name, info.Package, crossPackage)
+		}
+	}
+}
+
+func TestPackageSkipsHelpers(t *testing.T) {
+	info, _ := sherlock.InfoOf(thrown(func() { crosspkg.Must(errScoped) }))
+	if want := "github.com/alankm/sherlock_test"; info == nil || info.Package != want {
+		t.Fatalf("got %+v, want package %q", info, want)
+	}
+}
+
+func TestEventPackage(t *testing.T) {
+	ch := sherlock.Subscribe(func(e sherlock.Event) bool {
+		return errors.Is(e.Err, errScoped)
+	})
+	defer sherlock.Unsubscribe(ch)
+	var err error
+	func() {
+		defer sherlock.CatchAll(&err)
+		crosspkg.Check(errScoped)
+	}()
+	select {
+	case e := <-ch:
+		if e.Package != crossPackage {
+			t.Fatalf("got package %q, want %q", e.Package, crossPackage)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event published")
+	}
+}
+
+func TestCatchAcrossPackages(t *testing.T) {
+	if !crosspkg.Caught(errScoped) {
+		t.Fatal("an error thrown and caught within one package was not caught")
+	}
+	r := thrown(func() {
+		defer sherlock.Catch(errScoped, func() {
+			t.Error("caught an error thrown by another package")
+		})
+		crosspkg.Check(errScoped)
+	})
+	if _, ok := sherlock.InfoOf(r); !ok {
+		t.Fatalf("expected the sherlock panic to be rethrown, got %v", r)
+	}
+}
+
+type failingCloser struct{ err error }
+
+func (c failingCloser) Close() error { return c.err }
+
+func TestScopeCloseNil(t *testing.T) {
+	errClose := errors.New("scope test: close")
+	err := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		s := sherlock.NewScope()
+		defer s.Close(nil)
+		s.Track(failingCloser{errClose})
+		return nil
+	}()
+	if !errors.Is(err, errClose) {
+		t.Errorf("got %v, want the error of the closer thrown", err)
+	}
+}
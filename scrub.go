@@ -0,0 +1,25 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import "regexp"
+
+var (
+	stackGoroutine = regexp.MustCompile(`(?m)(^goroutine|in goroutine) \d+`)
+	stackArgs      = regexp.MustCompile(`(?m)^(\S.*)\([^()]*\)$`)
+	stackFile      = regexp.MustCompile(`(?m)^\t(?:.*/)?([^/\s]+:\d+)(?: \+0x[0-9a-f]+)?$`)
+	number         = regexp.MustCompile(`0x[0-9a-fA-F]+|\d+`)
+)
+
+// scrubStack strips goroutine IDs, argument values, program counter offsets
+// and directories from a stack.
+func scrubStack(s string) string {
+	s = stackGoroutine.ReplaceAllString(s, "$1 N")
+	s = stackArgs.ReplaceAllString(s, "$1(...)")
+	return stackFile.ReplaceAllString(s, "\t$1")
+}
+
+// maskNumbers replaces every decimal or hexadecimal number in s with "#".
+func maskNumbers(s string) string {
+	return number.ReplaceAllString(s, "#")
+}
@@ -0,0 +1,125 @@
+//go:build tinygo || sherlock_tiny
+
+package sherlock
+
+import "strings"
+
+// The reduced build has no regexp engine, so stacks are scrubbed and numbers
+// masked by hand instead, with the same results.
+
+func scrubStack(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = scrubLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func scrubLine(line string) string {
+	line = maskGoroutine(line, "goroutine ", true)
+	line = maskGoroutine(line, "in goroutine ", false)
+	if line == "" {
+		return line
+	}
+	if line[0] == '\t' {
+		rest := line[1:]
+		if i := strings.LastIndex(rest, " +0x"); i >= 0 && isHex(rest[i+4:], false) {
+			rest = rest[:i]
+		}
+		if i := strings.LastIndexByte(rest, '/'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		if i := strings.LastIndexByte(rest, ':'); i > 0 && isDigits(rest[i+1:]) &&
+			!strings.ContainsAny(rest, " \t\v\f\r") {
+			return "\t" + rest
+		}
+		return line
+	}
+	if line[0] == ' ' || line[0] == '\v' || line[0] == '\f' || line[0] == '\r' {
+		return line
+	}
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndexByte(line, '('); i > 0 &&
+			!strings.ContainsAny(line[i+1:len(line)-1], "()") {
+			return line[:i] + "(...)"
+		}
+	}
+	return line
+}
+
+// maskGoroutine replaces the ID following each occurrence of prefix in line
+// with N, or only one at the start of the line if anchored.
+func maskGoroutine(line, prefix string, anchored bool) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(line, prefix)
+		if i < 0 || anchored && i > 0 {
+			break
+		}
+		j := i + len(prefix)
+		k := j
+		for k < len(line) && line[k] >= '0' && line[k] <= '9' {
+			k++
+		}
+		b.WriteString(line[:j])
+		if k > j {
+			b.WriteByte('N')
+		}
+		line = line[k:]
+		if anchored {
+			break
+		}
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+func maskNumbers(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < '0' || c > '9' {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		j := i + 1
+		if c == '0' && j < len(s) && s[j] == 'x' {
+			k := j + 1
+			for k < len(s) && isHex(s[k:k+1], true) {
+				k++
+			}
+			if k > j+1 {
+				j = k
+			}
+		} else {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+		}
+		b.WriteByte('#')
+		i = j
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isHex reports whether s is a non-empty run of hexadecimal digits, accepting
+// upper case digits only if upper.
+func isHex(s string, upper bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || upper && c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,122 @@
+package sherlock
+
+import "fmt"
+
+// Severity classifies errors and the diagnostics that sherlock writes about
+// them. From least to most severe the levels are SeverityInfo,
+// SeverityWarning, SeverityError and SeverityFatal, which is the order
+// CatchAtLeast and CatchAtMost compare them in. Their values do not follow that
+// order, as SeverityWarning and SeverityFatal were added later, so severities
+// should not be compared with < or >.
+type Severity int
+
+const (
+	// SeverityInfo is used for errors that were caught as intended and have
+	// no severity registered.
+	SeverityInfo Severity = iota
+	// SeverityError is used for panics that sherlock considers to be bugs, and
+	// is the severity of errors with none registered.
+	SeverityError
+	// SeverityWarning is for errors that are worth attention but expected.
+	SeverityWarning
+	// SeverityFatal is for errors that the process cannot recover from.
+	SeverityFatal
+)
+
+// atLeast reports whether s is at least as severe as min.
+func (s Severity) atLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+// rank orders severities from least to most severe.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityError:
+		return 2
+	}
+	return int(s)
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// parseSeverity returns the Severity whose String is s.
+func parseSeverity(s string) (Severity, bool) {
+	for sev := SeverityInfo; sev <= SeverityFatal; sev++ {
+		if sev.String() == s {
+			return sev, true
+		}
+	}
+	return 0, false
+}
+
+var severities table[Severity]
+
+// RegisterSeverity registers sev as the severity of err. It is also the severity
+// of the diagnostic written when err is caught, or observed by Ok, and so sets
+// the priority it is logged at by backends such as SyslogBackend.
+func RegisterSeverity(err error, sev Severity) {
+	severities.set(err, sev)
+}
+
+// SeverityOf returns the severity registered for err, or SeverityError if none
+// was registered. An error decoded by Decode has the severity it was encoded
+// with.
+func SeverityOf(err error) Severity {
+	if r := remoteOf(err); r != nil {
+		return r.severity
+	}
+	if sev, ok := severities.lookup(err); ok {
+		return sev
+	}
+	return SeverityError
+}
+
+// caughtSeverity returns the severity of the diagnostic written for err when it
+// is caught or observed as expected: the severity registered for it, or
+// SeverityInfo if none was.
+func caughtSeverity(err error) Severity {
+	if r := remoteOf(err); r != nil {
+		return r.severity
+	}
+	if sev, ok := severities.lookup(err); ok {
+		return sev
+	}
+	return SeverityInfo
+}
+
+// CatchAtLeast behaves like CatchAll for errors with a severity of at least
+// sev, and rethrows all other sherlock panics. It lets outer layers handle
+// severe errors while inner layers use CatchAtMost to absorb the rest.
+func CatchAtLeast(err *error, sev Severity) {
+	r := recover()
+	if x, ok := r.(*report); ok && !SeverityOf(x.err).atLeast(sev) {
+		record(r, nil, ActionRethrown)
+		panic(r)
+	}
+	catch(r, err)
+}
+
+// CatchAtMost behaves like CatchAll for errors with a severity of at most sev,
+// and rethrows all other sherlock panics.
+func CatchAtMost(err *error, sev Severity) {
+	r := recover()
+	if x, ok := r.(*report); ok && !sev.atLeast(SeverityOf(x.err)) {
+		record(r, nil, ActionRethrown)
+		panic(r)
+	}
+	catch(r, err)
+}
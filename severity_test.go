@@ -0,0 +1,68 @@
+package sherlock_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestSeverityValues(t *testing.T) {
+	if sherlock.SeverityInfo != 0 || sherlock.SeverityError != 1 {
+		t.Fatal("existing severities changed value")
+	}
+}
+
+// caughtAtLeast reports whether CatchAtLeast(sev) catches an error of severity
+// thrown.
+func caughtAtLeast(thrown, sev sherlock.Severity) (caught bool) {
+	errSev := errors.New("severity " + thrown.String())
+	sherlock.RegisterSeverity(errSev, thrown)
+	defer func() {
+		if recover() != nil {
+			caught = false
+		}
+	}()
+	var err error
+	func() {
+		defer sherlock.CatchAtLeast(&err, sev)
+		sherlock.Throw(errSev)
+	}()
+	return err != nil
+}
+
+func TestCatchAtLeastOrder(t *testing.T) {
+	sherlock.SetOutput(io.Discard)
+	defer sherlock.SetBackend(nil)
+	order := []sherlock.Severity{sherlock.SeverityInfo, sherlock.SeverityWarning, sherlock.SeverityError, sherlock.SeverityFatal}
+	for i, thrown := range order {
+		for j, sev := range order {
+			if got, want := caughtAtLeast(thrown, sev), i >= j; got != want {
+				t.Errorf("CatchAtLeast(%v) caught %v: %v, want %v", sev, thrown, got, want)
+			}
+		}
+	}
+}
+
+func TestCaughtSeverity(t *testing.T) {
+	r := record(t)
+	errWarn := errors.New("caught at warning")
+	sherlock.RegisterSeverity(errWarn, sherlock.SeverityWarning)
+	catch := func(err error) {
+		defer sherlock.CatchAll(&err)
+		sherlock.Throw(err)
+	}
+	catch(errWarn)
+	catch(errors.New("caught with none registered"))
+	sherlock.Ok(errWarn)
+	want := []sherlock.Severity{sherlock.SeverityWarning, sherlock.SeverityInfo, sherlock.SeverityWarning}
+	if len(r.entries) != len(want) {
+		t.Fatalf("got %d entries", len(r.entries))
+	}
+	for i, e := range r.entries {
+		if e.Severity != want[i] {
+			t.Errorf("entry %d %q has severity %v, want %v", i, e.Message, e.Severity, want[i])
+		}
+	}
+}
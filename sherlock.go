@@ -23,50 +23,104 @@ Written by Alan Murtagh
 package sherlock
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"reflect"
 	"runtime"
-	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type report struct {
-	err   error
-	stack string
-	pkg   string
+	err error
+	pcs []uintptr // the stack the error was thrown at, symbolised by stack
+	pkg string
+	id  string // the correlation ID of the context it was thrown in, if any
+
+	symbolise sync.Once
+	text      string
+}
+
+// stack returns the stack the error was thrown at. Symbolising it is
+// expensive, and most thrown errors are expected and never have their stack
+// shown, so it is formatted on first use rather than when thrown.
+func (x *report) stack() string {
+	x.symbolise.Do(func() {
+		x.text = formatStack(x.pcs)
+	})
+	return x.text
 }
 
 // Assert is used as a quick way to enforce contracts and to return custom
 // errors when a situation is possible but not intended to be recoverable.
-// If the condition is false, the provided error is thrown.
+// If the condition is false, the provided error is thrown, or ErrAssertion if
+// it is nil. The error goes through the same classification, counting and
+// diagnostics as an error thrown by Check, so it is mapped and reported alike.
 func Assert(condition bool, err error) {
 	if condition {
 		return
 	}
-	panic(&report{
-		err:   err,
-		stack: stacktrace(),
-		pkg:   caller(),
-	})
+	raise(nil, assertion(err), caller())
+}
+
+// ErrAssertion is thrown by an Assert that fails without an error of its own.
+// It is registered with the code "assertion_failed".
+var ErrAssertion = errors.New("assertion failed")
+
+// assertion returns the error thrown by a failed Assert given err.
+func assertion(err error) error {
+	if err == nil {
+		return ErrAssertion
+	}
+	return err
+}
+
+func init() {
+	RegisterCodeMapping(ErrAssertion, "assertion_failed")
 }
 
 // Catch halts a sherlock panic and checks if the thrown error is the same error
 // provided as an argument. If the errors match then the provided function is
 // executed and the panic is recovered. If the errors do not match then the
-// error is rethrown. Errors are equal only if they have the same address.
+// error is rethrown. Errors match if they are equal, or if errors.Is reports
+// that the thrown error matches, which honours wrapping as well as custom Is
+// methods on the thrown error.
+//
+// A panic not raised by sherlock is diagnosed as a bug and then given to the
+// policy set with SetForeignPolicy, as CatchAll does, and caught if the error
+// the policy assigns matches err. Under the default policy it is rethrown.
+//
+// As with CatchAll, an error thrown by another package is considered a bug.
+// The catching package is the one fn is declared in, which for the usual
+// function literal is the package of the function deferring Catch.
 func Catch(err error, fn func()) {
 	r := recover()
 	if r == nil {
 		return
 	}
 	x, ok := r.(*report)
-	if !ok || x.pkg != caller() {
-		fmt.Fprintf(os.Stderr, "%v", string(debug.Stack()))
+	if !ok {
+		e := foreign(r, bug(r))
+		if e == nil || !is(e, err) {
+			record(r, nil, ActionUnexpected)
+			panic(r)
+		}
+		record(r, e, ActionUnexpected)
+		fn()
+		return
+	}
+	if !samePackage(x.pkg, funcPackage(fn)) {
+		bug(r)
+		record(r, nil, ActionUnexpected)
 		panic(r)
 	}
-	if err == x.err {
+	if is(x.err, err) {
+		record(r, x.err, ActionCaught)
 		fn()
 	} else {
+		record(r, nil, ActionRethrown)
 		panic(r)
 	}
 }
@@ -77,74 +131,516 @@ func Catch(err error, fn func()) {
 // Sherlock can only catch sherlock thrown panics, and will rethrow a
 // non-sherlock panic. This behaviour can result in final stack traces being
 // difficult to use, but it is assumed that any non-sherlock panic is a bug, and
-// so sherlock will dump a stacktrace into stderr.
+// so sherlock will dump a stacktrace into stderr. SetForeignPolicy can be used
+// to have such panics assigned to err instead.
 //
 // Sherlock can also only catch panics thrown within the same package. It is
 // good practice to not let panics unwind beyond the boundaries of a package,
-// and so this is considered a bug by sherlock.
+// and so this is considered a bug by sherlock. Catch checks this against the
+// package of its fn, but the runtime does not reveal which function deferred
+// CatchAll, so CatchAll catches errors thrown by any package.
+//
+// If err already holds an error when a panic is caught, such as one the
+// function assigned to its named result before something later in it panicked,
+// the two are joined with errors.Join, so that neither is lost. SetOverwrite
+// can be used to have the caught error replace it instead.
 func CatchAll(err *error) {
-	r := recover()
+	catch(recover(), err)
+}
+
+var overwrite int32
+
+// SetOverwrite enables or disables overwriting errors when catching. When
+// enabled, CatchAll and the other catching functions replace any error already
+// held by the error pointer they are given, rather than joining the caught
+// error to it. It is disabled by default.
+func SetOverwrite(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&overwrite, v)
+}
+
+// assign stores the caught error e in *err, joining it to any error already
+// there unless SetOverwrite is enabled. An error that e already wraps, as when
+// the held error is the one that was checked, is replaced rather than joined.
+func assign(err *error, e error) {
+	if *err != nil && !sameError(*err, e) && !is(e, *err) && atomic.LoadInt32(&overwrite) == 0 {
+		e = errors.Join(*err, e)
+	}
+	*err = e
+}
+
+func catch(r interface{}, err *error) {
+	if x := catchQuietly(r, err); x != nil {
+		diagnose(caughtEntry(x))
+	}
+}
+
+// catchQuietly is catch without the diagnostic for a sherlock panic, for
+// callers that write their own. It returns the sherlock panic caught, if any.
+func catchQuietly(r interface{}, err *error) *report {
 	if r == nil {
-		err = nil
-		return
+		return nil
 	}
 	x, ok := r.(*report)
 	if !ok {
-		x, ok := r.(error)
-		if ok {
-			fmt.Fprintf(os.Stderr, "\n%v\n\n", x.Error())
+		e := foreign(r, bug(r))
+		record(r, e, ActionUnexpected)
+		if e == nil {
+			panic(r)
 		}
-		fmt.Fprintf(os.Stderr, "%v\n", string(debug.Stack()))
-		panic(r)
-	} else if x.pkg != caller() {
-		fmt.Fprintf(os.Stderr, "%v\n", x.err.Error())
-	} else {
-		fmt.Fprintf(os.Stderr, "%v\n", x.err.Error())
+		assign(err, e)
+		return nil
 	}
-	*err = x.err
+	record(r, x.err, ActionCaught)
+	assign(err, withInfo(x))
+	return x
 }
 
-// Check takes an arbitrary number of arguments and checks only the final one.
-// If the final argument is of type error and is non nil, it is thrown as a
-// sherlock panic.
+// caughtEntry is the diagnostic written when the sherlock panic x is caught.
+func caughtEntry(x *report) Entry {
+	return Entry{
+		Severity:      caughtSeverity(x.err),
+		Message:       x.err.Error(),
+		Hint:          Hint(x.err),
+		Package:       x.pkg,
+		CorrelationID: x.id,
+	}
+}
+
+// Check takes an arbitrary number of arguments and checks only the final one,
+// or every one if SetScanArgs is enabled. If the final argument is of type
+// error and is non nil, it is thrown as a sherlock panic. Any Op among the
+// other arguments is embedded in the thrown error.
+//
+// A final argument that is neither nil nor an error is ignored, as is a call
+// without arguments, unless SetStrict is enabled, which the "dev" profile does.
 func Check(args ...interface{}) {
+	if len(args) == 0 || args[len(args)-1] != nil || atomic.LoadInt32(intercepts) != 0 {
+		check(args)
+	}
+}
+
+var strict int32
+
+// SetStrict enables or disables strict checking. When enabled, Check panics
+// with a *MisuseError if it is called without arguments, or if its final
+// argument is neither nil nor an error, which usually means that the values
+// passed to it are in the wrong order. It is disabled by default.
+func SetStrict(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&strict, v)
+}
+
+// MisuseError is the value panicked with when sherlock is used incorrectly,
+// such as by a Check without an error to check while SetStrict is enabled. It
+// is a bug in the calling code rather than an error to handle, so it is not a
+// sherlock panic and is never caught as one.
+type MisuseError struct {
+	Message string
+}
+
+func (e *MisuseError) Error() string {
+	return "sherlock: " + e.Message
+}
+
+// intercepts holds a bit for each hook that could change the outcome of a
+// Check whose final argument is nil, so that the fast path of Check loads a
+// single word. It is a pointer only because that is a fraction cheaper to
+// load, which keeps Check within the inlining budget.
+var intercepts = new(int32)
+
+const (
+	interceptInjection int32 = 1 << iota
+	interceptTape
+	interceptScan
+)
+
+func setIntercept(bit int32, on bool) {
+	for {
+		old := atomic.LoadInt32(intercepts)
+		v := old &^ bit
+		if on {
+			v |= bit
+		}
+		if atomic.CompareAndSwapInt32(intercepts, old, v) {
+			return
+		}
+	}
+}
+
+// check is the slow path of Check, kept out of line so that Check stays small
+// enough to be inlined at every call site.
+//
+//go:noinline
+func check(args []interface{}) {
+	checkIn(nil, args, "", 3)
+}
+
+// checkIn checks args on behalf of check, Handle.check and CheckIn, attributing
+// any error to pkg, or to the calling package if pkg is empty, after passing it
+// through the classifiers of any overlays attached to ctx. The function that
+// called the public entry point is depth frames above checkIn, and is the call
+// site that fault injection and tapes are keyed to.
+func checkIn(ctx context.Context, args []interface{}, pkg string, depth int) {
 	l := len(args)
-	if args[l-1] == nil {
+	if l == 0 {
+		if atomic.LoadInt32(&strict) != 0 {
+			panic(&MisuseError{Message: "Check called without arguments"})
+		}
 		return
 	}
-	err, ok := args[l-1].(error)
-	if !ok {
+	var err error
+	if atomic.LoadInt32(intercepts)&interceptScan != 0 {
+		for _, arg := range args {
+			if e, ok := arg.(error); ok {
+				err = e
+				break
+			}
+		}
+		if err == nil {
+			err = injected(depth)
+		}
+	} else {
+		switch v := args[l-1].(type) {
+		case nil:
+			err = injected(depth)
+		case error:
+			err = v
+		default:
+			if atomic.LoadInt32(&strict) != 0 {
+				panic(&MisuseError{Message: fmt.Sprintf("Check called with a final argument of type %T, which is not an error", v)})
+			}
+		}
+	}
+	err = taped(err, depth)
+	if err == nil {
 		return
 	}
-	panic(&report{
-		err:   err,
-		stack: stacktrace(),
-		pkg:   caller(),
+	if pkg == "" {
+		pkg = caller()
+	}
+	if ctx != nil {
+		err = overlaid(ctx, err)
+	}
+	raise(ctx, withOps(args, err), pkg)
+}
+
+// CheckIn is like Check, but the error is first passed through the classifiers
+// of the overlays attached to ctx, so that the mappings of a request apply to
+// the errors checked while serving it and not only to the response.
+func CheckIn(ctx context.Context, args ...interface{}) {
+	checkIn(ctx, args, "", 2)
+}
+
+// SetScanArgs enables or disables scanning every argument of Check for an
+// error. When enabled, Check throws the first non-nil error among all of its
+// arguments rather than checking only the final one, for functions that return
+// their error in another position, such as (err error, ok bool). Final
+// arguments that are not errors are then expected, so SetStrict no longer
+// reports them. Scanning is disabled by default, as it gives up the inlined
+// fast path of Check.
+func SetScanArgs(enabled bool) {
+	setIntercept(interceptScan, enabled)
+}
+
+// CheckCtx throws ctx.Err() as a sherlock panic if ctx is done, which is
+// either context.Canceled or context.DeadlineExceeded, so that cancellation
+// flows through the same recovery path as other errors. If ctx was cancelled
+// with a cause, such as by NotifySignals, the cause is thrown instead. As with
+// CheckIn, the error is passed through the classifiers of the overlays
+// attached to ctx.
+func CheckCtx(ctx context.Context) {
+	if err := ctxErr(ctx); err != nil {
+		raise(ctx, overlaid(ctx, err), caller())
+	}
+}
+
+// ctxErr returns the error CheckCtx throws for ctx, or nil if ctx is not done.
+func ctxErr(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		err = cause
+	}
+	return err
+}
+
+// Ok reports whether err is nil. A non-nil err is classified, counted and
+// diagnosed exactly as if it were thrown and caught, but Ok returns false
+// instead of panicking, for call sites that want to branch on the error
+// locally yet still have it observed like every other error.
+//
+//	if !sherlock.Ok(err) {
+//		return fallback
+//	}
+func Ok(err error) bool {
+	if err == nil {
+		return true
+	}
+	err = classify(err)
+	tally(err)
+	diagnose(Entry{
+		Severity: caughtSeverity(err),
+		Message:  err.Error(),
+		Hint:     Hint(err),
+		Package:  caller(),
 	})
+	record(err, err, ActionObserved)
+	return false
 }
 
 // Throw simply throws the provided error as a sherlock panic.
 func Throw(err error) {
+	raise(nil, err, caller())
+}
+
+// raise classifies and counts err, then throws it as a sherlock panic
+// attributed to pkg and carrying the correlation ID of ctx, which may be nil.
+func raise(ctx context.Context, err error, pkg string) {
+	err = classify(err)
+	tally(err)
+	traceThrow(err)
 	panic(&report{
-		err:   err,
-		stack: stacktrace(),
-		pkg:   caller(),
+		err: err,
+		pcs: callstack(),
+		pkg: pkg,
+		id:  correlationID(ctx),
 	})
 }
 
-func stacktrace() string {
-	// TODO: remove parts of stacktrace that exist due to this package.
-	return string(debug.Stack())
+// bug writes the diagnostics for a panic that sherlock considers to be a bug,
+// hands it on to any configured reporting, and returns the stack it was
+// recovered at.
+func bug(r interface{}) string {
+	stack := stacktrace()
+	e := Entry{
+		Severity:    SeverityError,
+		Message:     describe(r),
+		Hint:        panicHint(r),
+		Stack:       stack,
+		Fingerprint: panicFingerprint(r, stack),
+	}
+	if x, ok := r.(*report); ok {
+		e.Package = x.pkg
+		e.CorrelationID = x.id
+		e.Source = snippet(x.stack())
+	} else {
+		e.Source = snippet(stack)
+		spend(&PanicError{Value: r, Stack: stack})
+	}
+	diagnoseBug(e)
+	writeCrashReport(r, stack)
+	notify(r, stack)
+	watch()
+	return stack
+}
+
+var stackDepth int32
+
+// SetStackDepth caps the stacks sherlock captures at depth frames, not counting
+// those of the runtime or of sherlock itself, as deep stacks are slow to format
+// and bury the frames that matter. The cap applies to diagnostics and crash
+// reports as well as to the stacks carried by Info and PanicError. A depth of
+// zero or less captures full stacks, which is the default.
+func SetStackDepth(depth int) {
+	atomic.StoreInt32(&stackDepth, int32(depth))
+}
+
+// pcs pools the buffers that program counters are captured into, for the
+// same reason as stacks.
+var pcs = sync.Pool{
+	New: func() interface{} {
+		b := make([]uintptr, 64)
+		return &b
+	},
+}
+
+// callstack captures the program counters of the calling goroutine's stack,
+// starting at the function that called callstack.
+func callstack() []uintptr {
+	p := pcs.Get().(*[]uintptr)
+	for {
+		n := runtime.Callers(2, *p)
+		if n < len(*p) {
+			c := append([]uintptr(nil), (*p)[:n]...)
+			pcs.Put(p)
+			return c
+		}
+		*p = make([]uintptr, 2*len(*p))
+	}
+}
+
+// formatStack symbolises pcs in the format used by runtime.Stack, less the
+// goroutine header and argument values, subject to SetStackDepth.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if f.Function != "runtime.goexit" {
+			fmt.Fprintf(&b, "%v(...)\n\t%v:%v +0x%x\n", f.Function, f.File, f.Line, f.PC-f.Entry)
+		}
+		if !more {
+			break
+		}
+	}
+	s := b.String()
+	if depth := atomic.LoadInt32(&stackDepth); depth > 0 {
+		s = truncateStack(s, int(depth))
+	}
+	return s
 }
 
-// NOTE: caller determines the calling package by skipping up the stack and
-// determining which package the calling function's calling function came from.
-// Take care to ensure it is never used any further down the stack.
+// truncateStack cuts stack off before the first frame beyond depth, in the way
+// the runtime itself elides frames.
+func truncateStack(stack string, depth int) string {
+	n := 0
+	for i := 0; i < len(stack); {
+		j := strings.IndexByte(stack[i:], '\n')
+		if j < 0 {
+			break
+		}
+		line := stack[i : i+j]
+		if line != "" && line[0] != '\t' && !strings.HasPrefix(line, "goroutine ") {
+			fn := line
+			if k := strings.LastIndex(fn, "("); k > 0 {
+				fn = fn[:k]
+			}
+			if !internalFrame(fn) {
+				if n == depth {
+					return stack[:i] + "...additional frames elided...\n"
+				}
+				n++
+			}
+		}
+		i += j + 1
+	}
+	return stack
+}
+
+// callers caches the functions each program counter resolves to, inlined
+// calls included, as the same few call sites are resolved over and over.
+var callers sync.Map
+
+// site is a function a program counter resolves to, and its package.
+type site struct {
+	fn  string
+	pkg string
+}
+
+// sites returns the functions pc resolves to, innermost first.
+func sites(pc uintptr) []site {
+	if v, ok := callers.Load(pc); ok {
+		return v.([]site)
+	}
+	var s []site
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		s = append(s, site{fn: frame.Function, pkg: packagePath(frame.Function)})
+		if !more {
+			break
+		}
+	}
+	callers.Store(pc, s)
+	return s
+}
+
+// caller returns the package of the first function up the stack that belongs
+// neither to the runtime nor to sherlock, and is not marked as a helper, which
+// is the package sherlock was called from.
+//
+// If the package cannot be determined, as can happen for calls made through
+// reflection, cgo or generated trampolines, caller writes a warning and returns
+// unknownPackage rather than failing.
 func caller() string {
-	_, file, _, ok := runtime.Caller(1)
-	if !ok {
-		panic(nil)
+	var pcs [32]uintptr
+	for skip := 2; ; {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			for _, s := range sites(pc) {
+				if internalFrame(s.fn) || isHelper(s.fn) {
+					continue
+				}
+				if s.pkg == "" {
+					unresolved(s.fn)
+					return unknownPackage
+				}
+				return s.pkg
+			}
+		}
+		if n < len(pcs) {
+			return unknownPackage
+		}
+		skip += n
 	}
-	i := strings.LastIndex(file, "/")
-	return file[:i]
+}
+
+var unresolvedFuncs sync.Map
+
+// unresolved warns, once for each function, that the package of fn could not
+// be determined.
+func unresolved(fn string) {
+	if _, seen := unresolvedFuncs.LoadOrStore(fn, struct{}{}); seen {
+		return
+	}
+	emit(Entry{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf("sherlock: could not determine the package of %q, so it is not scoped", fn),
+	})
+}
+
+// funcPackage returns the package fn is declared in, or unknownPackage if it is
+// nil or declared by sherlock itself.
+func funcPackage(fn func()) string {
+	if fn == nil {
+		return unknownPackage
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	pkg := packagePath(name)
+	if pkg == "" || internalFrame(name) {
+		return unknownPackage
+	}
+	return pkg
+}
+
+// packagePath returns the canonical import path of the package declaring the
+// function named fn, as reported by runtime.Frame.Function, or "" if it has
+// none. Packages are identified by import path rather than by directory, so
+// that a package is the same package wherever its source lives, and any vendor
+// prefix is removed, so that a vendored copy of a package is scoped as the
+// package it is a copy of.
+func packagePath(fn string) string {
+	if i := strings.Index(fn, "["); i >= 0 {
+		fn = fn[:i] // type arguments may themselves contain paths
+	}
+	slash := strings.LastIndex(fn, "/") + 1
+	dot := strings.Index(fn[slash:], ".")
+	if dot < 0 {
+		return ""
+	}
+	pkg := strings.ReplaceAll(fn[:slash+dot], "%2e", ".")
+	if i := strings.LastIndex(pkg, "/vendor/"); i >= 0 {
+		pkg = pkg[i+len("/vendor/"):]
+	} else if strings.HasPrefix(pkg, "vendor/") {
+		pkg = pkg[len("vendor/"):]
+	}
+	return pkg
+}
+
+// unknownPackage stands in for a package caller could not determine. It is
+// treated as the same package as every other, so that errors passing through
+// an unresolvable frame are still caught rather than reported as bugs.
+const unknownPackage = "?"
+
+func samePackage(a, b string) bool {
+	return a == b || a == unknownPackage || b == unknownPackage
 }
@@ -0,0 +1,48 @@
+package sherlock_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+var errDiskFull = errors.New("disk full")
+
+func TestCatchAllReplacesCheckedError(t *testing.T) {
+	sherlock.SetInfo(true)
+	defer sherlock.SetInfo(false)
+	err := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		err = errDiskFull
+		sherlock.Check(err)
+		return nil
+	}()
+	if !errors.Is(err, errDiskFull) || err.Error() != errDiskFull.Error() {
+		t.Fatalf("got %q, want %q", err, errDiskFull)
+	}
+}
+
+func TestCatchAllJoinsEarlierError(t *testing.T) {
+	errEarlier := errors.New("earlier")
+	err := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		err = errEarlier
+		sherlock.Check(errDiskFull)
+		return nil
+	}()
+	if !errors.Is(err, errEarlier) || !errors.Is(err, errDiskFull) {
+		t.Fatalf("got %q, want both errors", err)
+	}
+}
+
+func TestCheckWithoutArguments(t *testing.T) {
+	h := sherlock.Bind()
+	var none []interface{}
+	empty := []interface{}{}
+	sherlock.Check()
+	sherlock.Check(none...)
+	sherlock.Check(empty...)
+	h.Check()
+	h.Check(empty...)
+}
@@ -0,0 +1,149 @@
+/*
+Package sherlockassert provides assertions whose failures are thrown as
+sherlock errors, so that the same checks can be used in production code, where
+a failure is a recoverable error, and in tests, where it fails the test.
+
+	func Transfer(from, to *Account, amount int) (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlockassert.True(amount > 0, "amount must be positive")
+		sherlockassert.NoError(from.Withdraw(amount))
+		...
+	}
+
+In tests, New returns the same assertions failing the test instead. The package
+does not import testing, so production code can use it without linking the
+testing package and registering its flags.
+*/
+package sherlockassert
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/alankm/sherlock"
+)
+
+// ErrAssertion is matched by every assertion failure.
+var ErrAssertion = errors.New("assertion failed")
+
+func init() {
+	sherlock.RegisterCodeMapping(ErrAssertion, "assertion_failed")
+}
+
+// Failure is the error thrown by a failed assertion.
+type Failure struct {
+	Msg string
+}
+
+func (f *Failure) Error() string {
+	return "assertion failed: " + f.Msg
+}
+
+// Is reports whether target is ErrAssertion.
+func (f *Failure) Is(target error) bool {
+	return target == ErrAssertion
+}
+
+// failure formats the message of a failed assertion, with any message and
+// arguments provided by the caller appended.
+func failure(msg string, msgAndArgs []interface{}) string {
+	if len(msgAndArgs) == 0 {
+		return msg
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return msg + ": " + fmt.Sprintf(format, msgAndArgs[1:]...)
+	}
+	return msg + ": " + fmt.Sprint(msgAndArgs...)
+}
+
+// throw throws the Failure for a failed assertion to the caller of the
+// assertion, which is expected to catch it.
+//
+//sherlocklint:ignore
+func throw(msg string, msgAndArgs []interface{}) {
+	sherlock.Throw(&Failure{Msg: failure(msg, msgAndArgs)})
+}
+
+// True asserts that cond is true.
+func True(cond bool, msgAndArgs ...interface{}) {
+	if !cond {
+		throw("expected true", msgAndArgs)
+	}
+}
+
+// Equal asserts that expected and actual are deeply equal.
+func Equal(expected, actual interface{}, msgAndArgs ...interface{}) {
+	if !reflect.DeepEqual(expected, actual) {
+		throw(fmt.Sprintf("expected %#v, got %#v", expected, actual), msgAndArgs)
+	}
+}
+
+// NoError asserts that err is nil.
+func NoError(err error, msgAndArgs ...interface{}) {
+	if err != nil {
+		throw(fmt.Sprintf("unexpected error: %v", err), msgAndArgs)
+	}
+}
+
+// ErrorIs asserts that errors.Is(err, target) is true.
+func ErrorIs(err, target error, msgAndArgs ...interface{}) {
+	if !errors.Is(err, target) {
+		throw(fmt.Sprintf("expected error matching %q, got %v", target, err), msgAndArgs)
+	}
+}
+
+// TB is the part of testing.TB that Assertions report failures through.
+type TB interface {
+	Helper()
+	Fatal(args ...interface{})
+}
+
+// Assertions are the assertions of this package reporting failures through a
+// TB rather than by throwing.
+type Assertions struct {
+	t TB
+}
+
+// New returns assertions that fail the test t immediately when they fail. t is
+// usually a *testing.T.
+func New(t TB) *Assertions {
+	return &Assertions{t: t}
+}
+
+func (a *Assertions) check(fn func()) {
+	a.t.Helper()
+	if err := run(fn); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+func run(fn func()) (err error) {
+	defer sherlock.CatchAll(&err)
+	fn()
+	return nil
+}
+
+// True asserts that cond is true.
+func (a *Assertions) True(cond bool, msgAndArgs ...interface{}) {
+	a.t.Helper()
+	a.check(func() { True(cond, msgAndArgs...) })
+}
+
+// Equal asserts that expected and actual are deeply equal.
+func (a *Assertions) Equal(expected, actual interface{}, msgAndArgs ...interface{}) {
+	a.t.Helper()
+	a.check(func() { Equal(expected, actual, msgAndArgs...) })
+}
+
+// NoError asserts that err is nil.
+func (a *Assertions) NoError(err error, msgAndArgs ...interface{}) {
+	a.t.Helper()
+	a.check(func() { NoError(err, msgAndArgs...) })
+}
+
+// ErrorIs asserts that errors.Is(err, target) is true.
+func (a *Assertions) ErrorIs(err, target error, msgAndArgs ...interface{}) {
+	a.t.Helper()
+	a.check(func() { ErrorIs(err, target, msgAndArgs...) })
+}
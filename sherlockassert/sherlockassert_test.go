@@ -0,0 +1,54 @@
+package sherlockassert_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+	"github.com/alankm/sherlock/sherlockassert"
+)
+
+type fatalRecorder struct {
+	failed string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatal(args ...interface{}) {
+	r.failed = fmt.Sprint(args...)
+}
+
+func TestNew(t *testing.T) {
+	var r fatalRecorder
+	sherlockassert.New(t).True(true)
+	sherlockassert.New(&r).Equal(1, 2)
+	if !strings.Contains(r.failed, "expected 1, got 2") {
+		t.Fatalf("got failure %q", r.failed)
+	}
+}
+
+func TestThrown(t *testing.T) {
+	err := func() (err error) {
+		defer sherlock.CatchAll(&err)
+		sherlockassert.True(false, "amount %d", -1)
+		return nil
+	}()
+	if !errors.Is(err, sherlockassert.ErrAssertion) || sherlock.Code(err) != "assertion_failed" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestNoTestingImport(t *testing.T) {
+	out, err := exec.Command("go", "list", "-deps", "github.com/alankm/sherlock/sherlockassert").Output()
+	if err != nil {
+		t.Skip("go list unavailable:", err)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		if dep == "testing" {
+			t.Fatal("sherlockassert depends on testing")
+		}
+	}
+}
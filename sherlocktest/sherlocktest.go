@@ -0,0 +1,149 @@
+/*
+Package sherlocktest provides helpers for testing code that uses sherlock.
+
+	func TestLoad(t *testing.T) {
+		defer sherlocktest.CatchT(t)
+		sherlocktest.RequirePanicsWith(t, ErrNotFound, func() {
+			load("missing")
+		})
+		...
+	}
+*/
+package sherlocktest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+// CatchT must be deferred at the top of a test. It converts a sherlock panic
+// escaping the test into a call to t.Fatal, reporting the error along with the
+// stack it was thrown from, cleaned of runtime and sherlock frames. Other
+// panics are rethrown.
+func CatchT(t testing.TB) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	t.Helper()
+	info, ok := sherlock.InfoOf(r)
+	if !ok {
+		panic(r)
+	}
+	t.Fatalf("%v\n%v", info, clean(info.Stack))
+}
+
+// RequirePanicsWith runs fn and fails the test unless it throws a sherlock
+// panic with an error matching target.
+func RequirePanicsWith(t testing.TB, target error, fn func()) {
+	t.Helper()
+	r := capture(fn)
+	if r == nil {
+		t.Fatalf("expected a panic matching %q, got none", target)
+	}
+	info, ok := sherlock.InfoOf(r)
+	if !ok {
+		t.Fatalf("expected a sherlock panic matching %q, got %v", target, r)
+	}
+	if !errors.Is(info, target) {
+		t.Fatalf("expected a panic matching %q, got %v\n%v", target, info, clean(info.Stack))
+	}
+}
+
+// RequireMaps fails the test unless err, once thrown, matches sentinel. This
+// takes any registered classifiers into account.
+//
+//sherlocklint:ignore
+func RequireMaps(t testing.TB, err, sentinel error) {
+	t.Helper()
+	r := capture(func() { sherlock.Throw(err) })
+	info, ok := sherlock.InfoOf(r)
+	if !ok || !errors.Is(info, sentinel) {
+		t.Fatalf("expected %q to map to %q", err, sentinel)
+	}
+}
+
+// RequireCode fails the test unless err has the given code.
+func RequireCode(t testing.TB, err error, code string) {
+	t.Helper()
+	if got := sherlock.Code(err); got != code {
+		t.Fatalf("expected %q to have code %q, got %q", err, code, got)
+	}
+}
+
+// RunCovered runs the tests of m, as TestMain would, with sherlock counting the
+// errors thrown against each registered error, and returns the exit code for
+// the run. Once the tests have run it prints the registered errors that no
+// thrown error matched, which are the registrations the suite never exercised,
+// leaving out those sherlock registers for its own errors. If fail is set, any
+// such errors fail a run that otherwise passed.
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(sherlocktest.RunCovered(m, false))
+//	}
+func RunCovered(m *testing.M, fail bool) int {
+	sherlock.SetRuleHits(true)
+	code := m.Run()
+	missed := uncovered()
+	if len(missed) == 0 {
+		return code
+	}
+	fmt.Fprintf(os.Stderr, "sherlock: %v registered errors were never thrown:\n", len(missed))
+	for _, err := range missed {
+		fmt.Fprintf(os.Stderr, "\t%v\n", err)
+	}
+	if fail && code == 0 {
+		code = 1
+	}
+	return code
+}
+
+// uncovered returns the registered errors without hits, other than sherlock's
+// own.
+func uncovered() []error {
+	var missed []error
+	for _, h := range sherlock.RuleHits() {
+		switch h.Err {
+		case sherlock.ErrAssertion, sherlock.ErrCircuitOpen, sherlock.ErrShutdown, sherlock.ErrTimeout:
+			continue
+		}
+		if h.Hits == 0 {
+			missed = append(missed, h.Err)
+		}
+	}
+	return missed
+}
+
+func capture(fn func()) (r interface{}) {
+	defer func() {
+		r = recover()
+	}()
+	fn()
+	return nil
+}
+
+// clean removes the runtime and sherlock frames from a stack.
+func clean(stack string) string {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if strings.HasPrefix(line, "runtime") || strings.HasPrefix(line, "panic(") ||
+			strings.HasPrefix(line, "github.com/alankm/sherlock.") {
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+				i++
+			}
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
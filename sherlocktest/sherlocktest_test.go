@@ -0,0 +1,30 @@
+package sherlocktest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestUncovered(t *testing.T) {
+	errThrown := errors.New("covered sentinel")
+	errMissed := errors.New("uncovered sentinel")
+	sherlock.RegisterCodeMapping(errThrown, "covered")
+	sherlock.RegisterCodeMapping(errMissed, "uncovered")
+	sherlock.SetRuleHits(true)
+	defer sherlock.SetRuleHits(false)
+	RequireMaps(t, errThrown, errThrown)
+	var missed []error
+	for _, err := range uncovered() {
+		if err == errThrown || err == errMissed {
+			missed = append(missed, err)
+		}
+		if err == sherlock.ErrAssertion {
+			t.Error("sherlock's own errors reported as uncovered")
+		}
+	}
+	if len(missed) != 1 || missed[0] != errMissed {
+		t.Fatalf("got uncovered %v", missed)
+	}
+}
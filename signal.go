@@ -0,0 +1,61 @@
+package sherlock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+)
+
+// ErrShutdown is matched by the errors NotifySignals cancels its context with.
+// It is registered with the code "shutdown" and exit code 0, so that Main
+// treats a requested shutdown as a clean exit.
+var ErrShutdown = errors.New("shutdown requested")
+
+func init() {
+	RegisterCodeMapping(ErrShutdown, "shutdown")
+	RegisterExitCode(ErrShutdown, 0)
+}
+
+// SignalError is the cause of a context cancelled by NotifySignals. It matches
+// both ErrShutdown and context.Canceled.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return "received signal: " + e.Signal.String()
+}
+
+func (e *SignalError) Is(target error) bool {
+	return target == ErrShutdown || target == context.Canceled
+}
+
+// NotifySignals returns a copy of ctx that is cancelled when one of sigs is
+// received, with a *SignalError as its cause, so that a main loop calling
+// CheckCtx unwinds through the same Catch and Main handling as any other
+// error. It is like signal.NotifyContext otherwise, and the returned stop
+// function must be called to release resources.
+//
+//	ctx, stop := sherlock.NotifySignals(ctx, os.Interrupt, syscall.SIGTERM)
+//	defer stop()
+//	for {
+//		sherlock.CheckCtx(ctx)
+//		...
+//	}
+func NotifySignals(ctx context.Context, sigs ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		select {
+		case sig := <-ch:
+			cancel(&SignalError{Signal: sig})
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel(nil)
+	}
+}
@@ -0,0 +1,89 @@
+package sherlock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var sourceLines int32
+
+// SetSourceLines enables source snippets in the diagnostics for unexpected
+// errors. When n is positive and the source files are available, as they
+// usually are in development, the n lines either side of the line in the first
+// frame outside the runtime and sherlock are included, with that line marked,
+// so that reports can be acted on without first opening the file. A value of
+// zero or less disables snippets, which is the default.
+func SetSourceLines(n int) {
+	atomic.StoreInt32(&sourceLines, int32(n))
+}
+
+// snippet returns the source around the first user frame in stack, or "" if
+// snippets are disabled or the source cannot be read.
+func snippet(stack string) string {
+	n := int(atomic.LoadInt32(&sourceLines))
+	if n <= 0 {
+		return ""
+	}
+	file, line, ok := userLocation(stack)
+	if !ok {
+		return ""
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(src), "\n")
+	if line > len(lines) {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v:%v:\n", filepath.Base(file), line)
+	width := len(strconv.Itoa(line + n))
+	for i := line - n; i <= line+n; i++ {
+		if i < 1 || i > len(lines) {
+			continue
+		}
+		mark := " "
+		if i == line {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%v %*d | %v\n", mark, width, i, strings.TrimRight(lines[i-1], "\r"))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// userLocation returns the file and line of the first frame in stack that
+// belongs neither to the runtime, to sherlock nor to a helper.
+func userLocation(stack string) (string, int, bool) {
+	lines := strings.Split(stack, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		fn := lines[i]
+		if fn == "" || fn[0] == '\t' || strings.HasPrefix(fn, "goroutine ") {
+			continue
+		}
+		if j := strings.LastIndex(fn, "("); j > 0 {
+			fn = fn[:j]
+		}
+		if internalFrame(fn) || isHelper(fn) {
+			continue
+		}
+		loc := strings.TrimPrefix(lines[i+1], "\t")
+		if j := strings.LastIndex(loc, " +0x"); j >= 0 {
+			loc = loc[:j]
+		}
+		j := strings.LastIndex(loc, ":")
+		if j < 0 {
+			return "", 0, false
+		}
+		line, err := strconv.Atoi(loc[j+1:])
+		if err != nil {
+			return "", 0, false
+		}
+		return loc[:j], line, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,60 @@
+package sherlock
+
+// Spec describes everything needed to present an error to a client: its
+// stable code, the HTTP status to respond with, and the public message.
+type Spec struct {
+	Code       string
+	HTTPStatus int
+	Message    string
+}
+
+var httpStatuses table[int]
+
+// RegisterHTTPStatus registers status as the HTTP status code to respond with
+// when err is caught while serving a request.
+func RegisterHTTPStatus(err error, status int) {
+	httpStatuses.set(err, status)
+}
+
+// HTTPStatus returns the HTTP status code for err. A Coded error in err's chain
+// with an HTTPStatus takes precedence over any registered status. Errors with
+// no status get 500 Internal Server Error.
+func HTTPStatus(err error) int {
+	if c := coded(err); c != nil && c.HTTPStatus != 0 {
+		return c.HTTPStatus
+	}
+	if status, ok := httpStatuses.lookup(err); ok {
+		return status
+	}
+	return 500
+}
+
+// RegisterSpec registers spec for err. It is shorthand for registering each of
+// its non-zero fields individually.
+func RegisterSpec(err error, spec Spec) {
+	if spec.Code != "" {
+		RegisterCodeMapping(err, spec.Code)
+	}
+	if spec.HTTPStatus != 0 {
+		RegisterHTTPStatus(err, spec.HTTPStatus)
+	}
+	if spec.Message != "" {
+		RegisterPublicMessage(err, spec.Message)
+	}
+}
+
+// Structured returns err as a *Coded carrying everything registered for it,
+// wrapping err so that errors.Is and errors.As continue to work. A nil err
+// returns nil.
+func Structured(err error) *Coded {
+	if err == nil {
+		return nil
+	}
+	return &Coded{
+		Code:       Code(err),
+		Message:    err.Error(),
+		Public:     PublicMessage(err),
+		HTTPStatus: HTTPStatus(err),
+		Err:        err,
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// stacks pools the buffers that goroutine stacks are formatted into, as
+// expected errors can be thrown often enough for them to burden the collector.
+// Buffers grown beyond maxPooledStack by unusually deep stacks are dropped
+// rather than being held onto by the pool.
+var stacks = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 4096)
+		return &b
+	},
+}
+
+const maxPooledStack = 64 << 10
+
+func stacktrace() string {
+	// TODO: remove parts of stacktrace that exist due to this package.
+	p := stacks.Get().(*[]byte)
+	for {
+		n := runtime.Stack(*p, false)
+		if n < len(*p) {
+			s := string((*p)[:n])
+			if len(*p) <= maxPooledStack {
+				stacks.Put(p)
+			}
+			if depth := atomic.LoadInt32(&stackDepth); depth > 0 {
+				s = truncateStack(s, int(depth))
+			}
+			return s
+		}
+		*p = make([]byte, 2*len(*p))
+	}
+}
@@ -0,0 +1,177 @@
+package sherlock
+
+import "sync/atomic"
+
+// State is a copy of everything registered with sherlock and of its settings,
+// taken by Snapshot.
+type State struct {
+	codes       tableState[string]
+	public      tableState[string]
+	statuses    tableState[int]
+	hints       tableState[string]
+	severities  tableState[Severity]
+	retryable   tableState[bool]
+	soft        tableState[bool]
+	messageKeys tableState[string]
+	exitCodes   tableState[int]
+	strategies  tableState[Strategy]
+
+	classifiers  []Classifier
+	handles      []uint64
+	translations map[string]map[string]string
+	domains      []domainState
+	injecting    bool
+	injections   []injectRule
+	budgets      []*ErrorBudget
+
+	foreignPolicy  int32
+	foreignHandler ForeignHandler
+	correlation    interface{}
+	info           int32
+	deterministic  int32
+	strict         int32
+	overwrite      int32
+	scanArgs       bool
+	matchMessages  int32
+	fuzzy          uint64
+	unwrapDepth    int32
+	stackDepth     int32
+	sourceLines    int32
+	ruleHits       int32
+	catalogVersion int32
+	sampler        *Sampler
+	backend        Backend
+	redactor       Redactor
+	reporting      reportingState
+}
+
+// Snapshot returns a copy of every registration, classifier and translation,
+// along with the domains, injected faults and tracked error budgets and the
+// settings made by sherlock's Set functions other than those for the audit
+// trail, batching, the stats file and problem types, so that tests and dynamic reconfiguration can return to a known state
+// with Restore.
+func Snapshot() State {
+	s := State{
+		codes:       codes.snapshot(),
+		public:      publicMessages.snapshot(),
+		statuses:    httpStatuses.snapshot(),
+		hints:       hints.snapshot(),
+		severities:  severities.snapshot(),
+		retryable:   retryable.snapshot(),
+		soft:        soft.snapshot(),
+		messageKeys: messageKeys.snapshot(),
+		exitCodes:   exitCodes.snapshot(),
+		strategies:  strategies.snapshot(),
+
+		foreignPolicy: atomic.LoadInt32(&foreignPolicy),
+		info:          atomic.LoadInt32(&attachInfo),
+		deterministic: atomic.LoadInt32(&deterministic),
+		strict:        atomic.LoadInt32(&strict),
+		overwrite:     atomic.LoadInt32(&overwrite),
+		scanArgs:      atomic.LoadInt32(intercepts)&interceptScan != 0,
+		matchMessages: atomic.LoadInt32(&matchMessages),
+		fuzzy:         atomic.LoadUint64(&fuzzyThreshold),
+		unwrapDepth:   atomic.LoadInt32(&unwrapDepth),
+		stackDepth:    atomic.LoadInt32(&stackDepth),
+		sourceLines:   atomic.LoadInt32(&sourceLines),
+		ruleHits:      atomic.LoadInt32(&countHits),
+
+		catalogVersion: atomic.LoadInt32(&catalogVersion),
+	}
+	s.foreignHandler, _ = foreignHandler.Load().(ForeignHandler)
+	box, _ := correlationKey.Load().(correlationBox)
+	s.correlation = box.key
+	classifiers.RLock()
+	s.classifiers = append([]Classifier(nil), classifiers.fns...)
+	s.handles = append([]uint64(nil), classifiers.handles...)
+	classifiers.RUnlock()
+	catalog.RLock()
+	s.translations = copyTranslations(catalog.m)
+	catalog.RUnlock()
+	s.domains = snapshotDomains()
+	injection.RLock()
+	s.injecting = atomic.LoadInt32(&injection.enabled) != 0
+	s.injections = append([]injectRule(nil), injection.rules...)
+	injection.RUnlock()
+	budgets.RLock()
+	s.budgets = append([]*ErrorBudget(nil), budgets.list...)
+	budgets.RUnlock()
+	sampler.Lock()
+	s.sampler = sampler.s
+	sampler.Unlock()
+	output.Lock()
+	s.backend = output.b
+	output.Unlock()
+	redactor.Lock()
+	s.redactor = redactor.fn
+	redactor.Unlock()
+	s.reporting = snapshotReporting()
+	return s
+}
+
+// Restore replaces every registration, classifier, translation and setting
+// with those in s. A State may be restored any number of times.
+//
+// Each table and setting is replaced in turn rather than all at once, so errors
+// handled by other goroutines while Restore runs may see some of the old state
+// and some of the new. Restore is meant for tests and for reconfiguration at
+// quiet moments, not for switching behaviour under load.
+func Restore(s State) {
+	codes.restore(s.codes)
+	publicMessages.restore(s.public)
+	httpStatuses.restore(s.statuses)
+	hints.restore(s.hints)
+	severities.restore(s.severities)
+	retryable.restore(s.retryable)
+	soft.restore(s.soft)
+	messageKeys.restore(s.messageKeys)
+	exitCodes.restore(s.exitCodes)
+	strategies.restore(s.strategies)
+
+	classifiers.Lock()
+	classifiers.fns = append([]Classifier(nil), s.classifiers...)
+	classifiers.handles = append([]uint64(nil), s.handles...)
+	classifiers.Unlock()
+	catalog.Lock()
+	catalog.m = copyTranslations(s.translations)
+	catalog.Unlock()
+	restoreDomains(s.domains)
+	injection.Lock()
+	injection.rules = append([]injectRule(nil), s.injections...)
+	injection.Unlock()
+	SetInjection(s.injecting)
+	budgets.Lock()
+	budgets.list = append([]*ErrorBudget(nil), s.budgets...)
+	budgets.Unlock()
+
+	atomic.StoreInt32(&foreignPolicy, s.foreignPolicy)
+	SetForeignHandler(s.foreignHandler)
+	SetCorrelationKey(s.correlation)
+	atomic.StoreInt32(&attachInfo, s.info)
+	atomic.StoreInt32(&deterministic, s.deterministic)
+	atomic.StoreInt32(&strict, s.strict)
+	atomic.StoreInt32(&overwrite, s.overwrite)
+	SetScanArgs(s.scanArgs)
+	atomic.StoreInt32(&matchMessages, s.matchMessages)
+	atomic.StoreUint64(&fuzzyThreshold, s.fuzzy)
+	atomic.StoreInt32(&unwrapDepth, s.unwrapDepth)
+	atomic.StoreInt32(&stackDepth, s.stackDepth)
+	atomic.StoreInt32(&sourceLines, s.sourceLines)
+	atomic.StoreInt32(&countHits, s.ruleHits)
+	atomic.StoreInt32(&catalogVersion, s.catalogVersion)
+	SetSampler(s.sampler)
+	SetBackend(s.backend)
+	SetRedactor(s.redactor)
+	restoreReporting(s.reporting)
+}
+
+func copyTranslations(m map[string]map[string]string) map[string]map[string]string {
+	c := make(map[string]map[string]string, len(m))
+	for lang, keys := range m {
+		c[lang] = make(map[string]string, len(keys))
+		for k, v := range keys {
+			c[lang][k] = v
+		}
+	}
+	return c
+}
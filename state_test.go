@@ -0,0 +1,73 @@
+package sherlock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestRestore(t *testing.T) {
+	errRegistered := errors.New("registered")
+	s := sherlock.Snapshot()
+	sherlock.RegisterCodeMapping(errRegistered, "registered")
+	sherlock.RegisterStrategy(errRegistered, sherlock.Strategy{})
+	sherlock.SetStrict(true)
+	sherlock.SetRedactor(func(string) string { return "redacted" })
+	sherlock.Restore(s)
+	if code := sherlock.Code(errRegistered); code != "" {
+		t.Errorf("code %q survived Restore", code)
+	}
+	if r := thrown(func() { sherlock.Check("not an error") }); r != nil {
+		t.Errorf("strict checking survived Restore: %v", r)
+	}
+}
+
+func TestRestoreDomainsInjectionBudgets(t *testing.T) {
+	errRaw := errors.New("state test: raw")
+	errClassified := errors.New("state test: classified")
+	errInjected := errors.New("state test: injected")
+	s := sherlock.Snapshot()
+	sherlock.Domain("strestore").RegisterClassifier(func(err error) error {
+		if errors.Is(err, errRaw) {
+			return errClassified
+		}
+		return nil
+	})
+	sherlock.Inject(1, errInjected, "")
+	sherlock.SetInjection(true)
+	b := &sherlock.ErrorBudget{Allowed: 10, Window: time.Minute, Fast: time.Minute}
+	sherlock.TrackBudget(b)
+	sherlock.Restore(s)
+
+	check := func(fn func()) (err error) {
+		defer sherlock.CatchAll(&err)
+		fn()
+		return nil
+	}
+	ctx := sherlock.InDomain(context.Background(), "strestore")
+	if err := check(func() { sherlock.CheckIn(ctx, errRaw) }); errors.Is(err, errClassified) {
+		t.Errorf("domain classifier survived Restore: %v", err)
+	}
+	if err := check(func() { sherlock.Check(nil) }); err != nil {
+		t.Errorf("injected fault survived Restore: %v", err)
+	}
+	if n := b.Remaining(); n != 10 {
+		t.Errorf("budget still tracked after Restore: %v remaining", n)
+	}
+}
+
+func TestCatalogStrategies(t *testing.T) {
+	errStrategy := errors.New("state test: strategy only")
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.RegisterStrategy(errStrategy, sherlock.Strategy{})
+	for _, e := range sherlock.Catalog() {
+		if e.Error == errStrategy.Error() {
+			return
+		}
+	}
+	t.Errorf("catalog lacks %q, registered only with a strategy", errStrategy)
+}
@@ -0,0 +1,61 @@
+package sherlock
+
+import (
+	"time"
+)
+
+// Strategy is the ordered response WithStrategy applies to a failure with a
+// particular error: retry, then fall back, then surface the error or crash.
+type Strategy struct {
+	// Retry, if its Attempts is more than 1, reruns the function up to that
+	// many times in all, as long as it keeps failing with an error that has
+	// a strategy registered.
+	Retry Backoff
+	// Fallback, if set, is called with the error once retries are used up,
+	// and its result replaces the error.
+	Fallback func(err error) error
+	// Crash makes an error that remains after the fallback panic with a
+	// value sherlock does not recognise, so that it is treated as a bug and
+	// rethrown by CatchAll under the default policy, rather than surfaced.
+	Crash bool
+}
+
+var strategies table[Strategy]
+
+// RegisterStrategy registers s as the strategy applied by WithStrategy to
+// failures with err.
+func RegisterStrategy(err error, s Strategy) {
+	strategies.set(err, s)
+}
+
+// WithStrategy runs fn and applies the strategy registered for the error it
+// fails with, thrown or returned, centralising the resilience decisions
+// alongside the other registrations. Errors without a strategy are returned
+// as they are.
+func WithStrategy(fn func() error) error {
+	err := attempt(fn)
+	if err == nil {
+		return nil
+	}
+	s, ok := strategies.lookup(err)
+	if !ok {
+		return err
+	}
+	for n := 1; n < s.Retry.Attempts; n++ {
+		time.Sleep(s.Retry.delay(n))
+		err = attempt(fn)
+		if err == nil {
+			return nil
+		}
+		if _, ok := strategies.lookup(err); !ok {
+			return err
+		}
+	}
+	if s.Fallback != nil {
+		err = s.Fallback(err)
+	}
+	if err != nil && s.Crash {
+		panic(err)
+	}
+	return err
+}
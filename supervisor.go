@@ -0,0 +1,197 @@
+package sherlock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Restart decides when a Supervisor restarts a child that has exited.
+type Restart int
+
+const (
+	// RestartOnUnexpected restarts a child only when it ends in a panic that
+	// was not raised by sherlock. This is the default.
+	RestartOnUnexpected Restart = iota
+	// RestartOnError restarts a child whenever it ends with an error.
+	RestartOnError
+	// RestartAlways restarts a child whenever it exits, even successfully.
+	RestartAlways
+	// RestartNever never restarts a child.
+	RestartNever
+)
+
+// ChildState is the lifecycle transition reported by a ChildEvent.
+type ChildState int
+
+const (
+	// ChildStarted is reported each time a child starts running, including
+	// after a restart.
+	ChildStarted ChildState = iota
+	// ChildExited is reported each time a child stops running, with the error
+	// it ended with.
+	ChildExited
+	// ChildGaveUp is reported when a child that would otherwise be restarted
+	// has used up its restarts.
+	ChildGaveUp
+	// ChildCrashLooping is reported when a child has ended in the same
+	// unexpected panic too often, before it is restarted after the cooldown.
+	ChildCrashLooping
+)
+
+// ChildEvent describes a lifecycle transition of a supervised child.
+type ChildEvent struct {
+	Time     time.Time
+	Name     string
+	State    ChildState
+	Err      error
+	Restarts int
+}
+
+// Supervisor runs long-lived goroutines, its children, each within a recovery
+// boundary, and restarts them according to Restart when they exit. A child
+// whose context is done is never restarted. Panics that were not raised by
+// sherlock are reported as bugs and end the child with a *PanicError.
+//
+//	var sup sherlock.Supervisor
+//	sup.Go(ctx, "poller", poll)
+//	sup.Wait()
+type Supervisor struct {
+	// Restart is the restart policy applied to every child.
+	Restart Restart
+	// MaxRestarts limits how many times each child is restarted. Zero means
+	// there is no limit.
+	MaxRestarts int
+	// Backoff spaces out the restarts of a child. Its Attempts field is not
+	// used.
+	Backoff Backoff
+	// Events, if set, is called with each lifecycle transition of a child.
+	Events func(ChildEvent)
+	// CrashLoop, if set, is the number of times a child may end in the same
+	// unexpected panic, by fingerprint, within CrashWindow before it is
+	// considered to be crash looping. A crash looping child is reported with
+	// a fatal diagnostic and a ChildCrashLooping event, and is restarted after
+	// CrashCooldown rather than after the usual backoff.
+	CrashLoop     int
+	CrashWindow   time.Duration
+	CrashCooldown time.Duration
+
+	wg      sync.WaitGroup
+	crashes crashTracker
+}
+
+// Go starts fn as a child named name.
+func (s *Supervisor) Go(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for restarts := 0; ; restarts++ {
+			s.event(ChildEvent{Name: name, State: ChildStarted, Restarts: restarts})
+			region := traceRegion(ctx, "Supervisor."+name)
+			unexpected, err := guard(func() error { return fn(ctx) })
+			region.End()
+			s.event(ChildEvent{Name: name, State: ChildExited, Err: err, Restarts: restarts})
+			if ctx.Err() != nil || !s.restart(err, unexpected) {
+				return
+			}
+			if s.MaxRestarts > 0 && restarts >= s.MaxRestarts {
+				s.event(ChildEvent{Name: name, State: ChildGaveUp, Err: err, Restarts: restarts})
+				return
+			}
+			delay := s.Backoff.delay(restarts + 1)
+			if p, ok := err.(*PanicError); ok && unexpected && s.CrashLoop > 0 {
+				fp := panicFingerprint(p.Value, p.Stack)
+				if seen := s.crashes.observe(fp, s.CrashWindow); seen >= s.CrashLoop {
+					s.event(ChildEvent{Name: name, State: ChildCrashLooping, Err: err, Restarts: restarts})
+					crashLooping(fp, seen, s.CrashCooldown)
+					delay = s.CrashCooldown
+				}
+			}
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Wait blocks until every child has stopped for good.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Supervisor) restart(err error, unexpected bool) bool {
+	switch s.Restart {
+	case RestartOnUnexpected:
+		return unexpected
+	case RestartOnError:
+		return err != nil
+	case RestartAlways:
+		return true
+	}
+	return false
+}
+
+func (s *Supervisor) event(e ChildEvent) {
+	if s.Events != nil {
+		e.Time = time.Now()
+		s.Events(e)
+	}
+}
+
+// guard runs fn, returning whether it ended in a panic that was not raised by
+// sherlock and the error it ended with. Such panics are reported as bugs and
+// converted into a *PanicError.
+func guard(fn func() error) (unexpected bool, err error) {
+	defer func() {
+		r := recover()
+		if _, ok := r.(*report); r == nil || ok {
+			catch(r, &err)
+			return
+		}
+		stack := bug(r)
+		err = &PanicError{Value: r, Stack: stack}
+		unexpected = true
+		record(r, err, ActionUnexpected)
+	}()
+	return false, classify(fn())
+}
+
+// crashTracker detects crash loops within a single process, for Supervisor.
+type crashTracker struct {
+	mu    sync.Mutex
+	times map[string][]time.Time
+}
+
+// observe records a crash with the given fingerprint and returns the number of
+// crashes with it within window.
+func (t *crashTracker) observe(fingerprint string, window time.Duration) int {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.times == nil {
+		t.times = make(map[string][]time.Time)
+	}
+	times := t.times[fingerprint]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) > window {
+		i++
+	}
+	times = append(times[i:], now)
+	t.times[fingerprint] = times
+	return len(times)
+}
+
+// crashLooping writes the diagnostic for a crash loop detected by Main or a
+// Supervisor.
+func crashLooping(fingerprint string, seen int, cooldown time.Duration) {
+	emit(Entry{
+		Severity:    SeverityFatal,
+		Message:     fmt.Sprintf("sherlock: crash loop detected: the same panic has occurred %v times, cooling down for %v", seen, cooldown),
+		Fingerprint: fingerprint,
+	})
+}
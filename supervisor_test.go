@@ -0,0 +1,105 @@
+package sherlock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alankm/sherlock"
+)
+
+// childEvents collects the events of a Supervisor.
+type childEvents struct {
+	sync.Mutex
+	events []sherlock.ChildEvent
+}
+
+func (c *childEvents) add(e sherlock.ChildEvent) {
+	c.Lock()
+	c.events = append(c.events, e)
+	c.Unlock()
+}
+
+func (c *childEvents) of(state sherlock.ChildState) []sherlock.ChildEvent {
+	c.Lock()
+	defer c.Unlock()
+	var out []sherlock.ChildEvent
+	for _, e := range c.events {
+		if e.State == state {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestSupervisorBackoff(t *testing.T) {
+	errFailed := errors.New("supervisor test: failed")
+	var events childEvents
+	sup := sherlock.Supervisor{
+		Restart:     sherlock.RestartOnError,
+		MaxRestarts: 2,
+		Backoff:     sherlock.Backoff{Initial: 10 * time.Millisecond, Multiplier: 2},
+		Events:      events.add,
+	}
+	sup.Go(context.Background(), "failing", func(context.Context) error { return errFailed })
+	sup.Wait()
+
+	started := events.of(sherlock.ChildStarted)
+	if len(started) != 3 {
+		t.Fatalf("started %v times, want 3", len(started))
+	}
+	for i, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond} {
+		if gap := started[i+1].Time.Sub(started[i].Time); gap < want {
+			t.Errorf("restart %v after %v, want at least %v", i+1, gap, want)
+		}
+	}
+	gaveUp := events.of(sherlock.ChildGaveUp)
+	if len(gaveUp) != 1 || !errors.Is(gaveUp[0].Err, errFailed) || gaveUp[0].Restarts != 2 {
+		t.Errorf("gave up with %+v", gaveUp)
+	}
+}
+
+func TestSupervisorRestartsUnexpected(t *testing.T) {
+	record(t)
+	var events childEvents
+	sup := sherlock.Supervisor{
+		Backoff: sherlock.Backoff{Initial: time.Millisecond},
+		Events:  events.add,
+	}
+	runs := 0
+	sup.Go(context.Background(), "flaky", func(context.Context) error {
+		runs++
+		if runs == 1 {
+			panic("supervisor test: bug")
+		}
+		return errors.New("supervisor test: expected")
+	})
+	sup.Wait()
+	if runs != 2 {
+		t.Fatalf("ran %v times, want a restart after the panic only", runs)
+	}
+	exited := events.of(sherlock.ChildExited)
+	var p *sherlock.PanicError
+	if len(exited) != 2 || !errors.As(exited[0].Err, &p) {
+		t.Errorf("exited with %+v, want a *PanicError first", exited)
+	}
+}
+
+func TestSupervisorStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sup := sherlock.Supervisor{Restart: sherlock.RestartAlways, Backoff: sherlock.Backoff{Initial: time.Hour}}
+	sup.Go(ctx, "waiting", func(context.Context) error { return nil })
+	done := make(chan struct{})
+	go func() {
+		sup.Wait()
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("child still waiting to restart after its context was cancelled")
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !windows && !plan9 && !js && !wasip1 && !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogSockets are the sockets local syslog daemons listen on, as tried by
+// log/syslog.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// logDaemon is the syslog facility diagnostics are logged under.
+const logDaemon = 3 << 3
+
+type syslogBackend struct {
+	tag string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// SyslogBackend returns a Backend that writes diagnostics to the local syslog
+// daemon using the given tag. Caught errors are logged at LOG_INFO and bugs at
+// LOG_ERR, with other severities mapped to the matching syslog priorities.
+// Each message is stamped with the time of its Entry rather than the time it
+// is sent, which differ while SetBatching holds entries back.
+func SyslogBackend(tag string) (Backend, error) {
+	b := &syslogBackend{tag: tag}
+	if err := b.connect(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *syslogBackend) connect() error {
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				b.conn = conn
+				return nil
+			}
+		}
+	}
+	return errors.New("sherlock: no local syslog daemon")
+}
+
+func (b *syslogBackend) Emit(e Entry) error {
+	msg := e.Message
+	if e.Hint != "" {
+		msg += "\nhint: " + e.Hint
+	}
+	if e.CorrelationID != "" {
+		msg += "\ncorrelation id: " + e.CorrelationID
+	}
+	if e.Source != "" {
+		msg += "\n" + e.Source
+	}
+	if e.Stack != "" {
+		msg += "\n" + e.Stack
+	}
+	priority := 6 // info
+	switch {
+	case e.Severity.atLeast(SeverityFatal):
+		priority = 2 // crit
+	case e.Severity.atLeast(SeverityError):
+		priority = 3 // err
+	case e.Severity.atLeast(SeverityWarning):
+		priority = 4 // warning
+	}
+	t := e.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	line := fmt.Sprintf("<%d>%s %s[%d]: %s", logDaemon|priority, t.Format(time.Stamp), b.tag, os.Getpid(), msg)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn != nil {
+		if _, err := b.conn.Write([]byte(line)); err == nil {
+			return nil
+		}
+		b.conn.Close()
+		b.conn = nil
+	}
+	if err := b.connect(); err != nil {
+		return err
+	}
+	_, err := b.conn.Write([]byte(line))
+	return err
+}
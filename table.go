@@ -0,0 +1,259 @@
+package sherlock
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// table maps registered errors to values of some kind. It is the common
+// storage behind every Register function.
+//
+// Lookups are on the path of every thrown error while registration is rare, so
+// the contents are held behind an atomic pointer that lookups load without
+// locking. Registrations copy the contents, modify the copy, and swap it in.
+type table[V any] struct {
+	mu    sync.Mutex // serialises writers
+	state atomic.Pointer[tableState[V]]
+}
+
+// tableState is a copy of the contents of a table. Once it has been stored in
+// a table it is never modified.
+type tableState[V any] struct {
+	m     map[error]V   // errors of comparable types
+	loose []looseKey[V] // errors of types that cannot be map keys
+	keys  []error       // in registration order
+}
+
+// generation counts changes to the contents of every table, so that results
+// derived from the registrations can be reused until the next change.
+var generation uint64
+
+// looseKey is a registration of an error whose dynamic type is not comparable,
+// such as a struct holding a slice. Such an error cannot be a map key or be
+// compared with ==, so it is instead matched by its type and message.
+type looseKey[V any] struct {
+	err error
+	v   V
+}
+
+func (t *table[V]) load() *tableState[V] {
+	if s := t.state.Load(); s != nil {
+		return s
+	}
+	return &tableState[V]{}
+}
+
+func (t *table[V]) set(err error, v V) {
+	t.mu.Lock()
+	s := t.load().clone()
+	if hashable(err) {
+		if _, ok := s.m[err]; !ok {
+			s.keys = append(s.keys, err)
+		}
+		s.m[err] = v
+	} else if i := s.looseIndex(err); i >= 0 {
+		s.loose[i].v = v
+	} else {
+		s.loose = append(s.loose, looseKey[V]{err, v})
+		s.keys = append(s.keys, err)
+	}
+	t.state.Store(s)
+	atomic.AddUint64(&generation, 1)
+	t.mu.Unlock()
+}
+
+// override registers v for err, like set, and returns a function that undoes
+// just that registration, restoring err's previous value or removing it.
+func (t *table[V]) override(err error, v V) (undo func()) {
+	prev, existed := t.load().exact(err)
+	t.set(err, v)
+	return func() {
+		if existed {
+			t.set(err, prev)
+			return
+		}
+		t.mu.Lock()
+		s := t.load().clone()
+		if hashable(err) {
+			delete(s.m, err)
+		} else if i := s.looseIndex(err); i >= 0 {
+			s.loose = append(s.loose[:i], s.loose[i+1:]...)
+		}
+		for i, key := range s.keys {
+			if sameError(key, err) {
+				s.keys = append(s.keys[:i], s.keys[i+1:]...)
+				break
+			}
+		}
+		t.state.Store(s)
+		atomic.AddUint64(&generation, 1)
+		t.mu.Unlock()
+	}
+}
+
+// exact returns the value registered for err itself, without the matching of
+// lookup.
+func (s *tableState[V]) exact(err error) (v V, ok bool) {
+	if hashable(err) {
+		v, ok = s.m[err]
+		return v, ok
+	}
+	if i := s.looseIndex(err); i >= 0 {
+		return s.loose[i].v, true
+	}
+	return v, false
+}
+
+// lookup returns the value registered for err. An exact match is tried first,
+// ignoring any annotations or fields sherlock has wrapped err in. Failing that,
+// each registered error is tried in registration order with the semantics of
+// errors.Is, so that errors wrapping a registered sentinel still match. If
+// SetMatchByMessage is enabled, an error in err's chain with the same message
+// as a registered error matches as a last resort.
+//
+// A panic from a method of err, or of a registered error, such as a custom Is,
+// is reported and the lookup treated as finding nothing.
+func (t *table[V]) lookup(err error) (v V, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			v, ok = zero, false
+			misbehaved(fmt.Sprintf("looking up %T", err), r)
+		}
+	}()
+	s := t.load()
+	for _, e := range [...]error{err, unannotate(err)} {
+		if hashable(e) {
+			if v, ok := s.m[e]; ok {
+				return v, true
+			}
+		}
+	}
+	if err == nil {
+		return v, false
+	}
+	for _, key := range s.keys {
+		if is(err, key) {
+			return s.value(key), true
+		}
+	}
+	if atomic.LoadInt32(&matchMessages) != 0 {
+		for _, key := range s.keys {
+			msg := key.Error()
+			if walk(err, func(e error) bool { return e.Error() == msg }) {
+				return s.value(key), true
+			}
+		}
+	}
+	return v, false
+}
+
+// value returns the value registered for key, which must be one of s.keys.
+func (s *tableState[V]) value(key error) V {
+	if hashable(key) {
+		return s.m[key]
+	}
+	return s.loose[s.looseIndex(key)].v
+}
+
+// looseIndex returns the index in s.loose of the registration of err, or -1.
+func (s *tableState[V]) looseIndex(err error) int {
+	for i, l := range s.loose {
+		if sameError(l.err, err) {
+			return i
+		}
+	}
+	return -1
+}
+
+// key returns the first registered error, in registration order, whose value
+// satisfies match.
+func (t *table[V]) key(match func(V) bool) (error, bool) {
+	s := t.load()
+	for _, key := range s.keys {
+		if match(s.value(key)) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// registered returns the registered errors in registration order.
+func (t *table[V]) registered() []error {
+	return append([]error(nil), t.load().keys...)
+}
+
+// shrink replaces the contents with a copy sized exactly to them, releasing
+// the space left behind by maps and slices grown through many registrations.
+func (t *table[V]) shrink() {
+	t.mu.Lock()
+	old := t.load()
+	s := &tableState[V]{
+		m:     make(map[error]V, len(old.m)),
+		loose: append([]looseKey[V](nil), old.loose...),
+		keys:  append(make([]error, 0, len(old.keys)), old.keys...),
+	}
+	for k, v := range old.m {
+		s.m[k] = v
+	}
+	t.state.Store(s)
+	t.mu.Unlock()
+}
+
+// snapshot and restore share the contents rather than copying them, which is
+// safe because stored contents are never modified.
+func (t *table[V]) snapshot() tableState[V] {
+	return *t.load()
+}
+
+func (t *table[V]) restore(s tableState[V]) {
+	t.mu.Lock()
+	t.state.Store(&s)
+	atomic.AddUint64(&generation, 1)
+	t.mu.Unlock()
+}
+
+func (s *tableState[V]) clone() *tableState[V] {
+	c := &tableState[V]{
+		m:     make(map[error]V, len(s.m)+1),
+		loose: append([]looseKey[V](nil), s.loose...),
+		keys:  append([]error(nil), s.keys...),
+	}
+	for k, v := range s.m {
+		c.m[k] = v
+	}
+	return c
+}
+
+var matchMessages int32
+
+// SetMatchByMessage enables or disables matching registrations by message.
+// When enabled, an error that matches no registration by identity or
+// errors.Is is looked up by message instead, so that distinct errors created
+// with the same text, such as by errors.New in different places, share the
+// registration of whichever was registered. It is disabled by default. For
+// messages that are similar rather than identical, see SetFuzzyMatch.
+func SetMatchByMessage(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&matchMessages, v)
+}
+
+// hashable reports whether err can be used as a map key and compared with ==
+// without panicking.
+func hashable(err error) bool {
+	return err == nil || reflect.TypeOf(err).Comparable()
+}
+
+// sameError reports whether a and b are equal, or, for errors that cannot be
+// compared, whether they have the same type and message.
+func sameError(a, b error) bool {
+	if hashable(a) && hashable(b) {
+		return a == b
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && a.Error() == b.Error()
+}
@@ -0,0 +1,132 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// TapeEvent is an error recorded flowing through Check. Site is the name of the
+// function that called Check and Call counts the calls made from that site,
+// from 1, so that replay can substitute the error at exactly the same point.
+type TapeEvent struct {
+	Site    string `json:"site"`
+	Call    int    `json:"call"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+const (
+	tapeOff int32 = iota
+	tapeRecording
+	tapeReplaying
+)
+
+var tape struct {
+	mode int32
+	sync.Mutex
+	enc    *json.Encoder
+	calls  map[string]int
+	replay map[string]map[int]TapeEvent
+}
+
+// Record starts recording every error thrown by Check to w, one JSON encoded
+// TapeEvent per line, until StopTape is called. Recording and replay cannot be
+// active at the same time.
+func Record(w io.Writer) {
+	tape.Lock()
+	tape.enc = json.NewEncoder(w)
+	tape.calls = make(map[string]int)
+	tape.replay = nil
+	tape.Unlock()
+	atomic.StoreInt32(&tape.mode, tapeRecording)
+	setIntercept(interceptTape, true)
+}
+
+// Replay reads events written by Record from r and substitutes them back in, so
+// that a failure seen in a real run can be reproduced exactly in a test: the
+// Check making the recorded call at each recorded site throws the recorded
+// error, whatever it was passed. Errors are rebuilt from their code if it is
+// registered with RegisterCodeMapping, and from their message otherwise.
+// Replay lasts until StopTape is called.
+func Replay(r io.Reader) error {
+	events := make(map[string]map[int]TapeEvent)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		var e TapeEvent
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return err
+		}
+		if events[e.Site] == nil {
+			events[e.Site] = make(map[int]TapeEvent)
+		}
+		events[e.Site][e.Call] = e
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	tape.Lock()
+	tape.enc = nil
+	tape.calls = make(map[string]int)
+	tape.replay = events
+	tape.Unlock()
+	atomic.StoreInt32(&tape.mode, tapeReplaying)
+	setIntercept(interceptTape, true)
+	return nil
+}
+
+// StopTape stops any recording or replay.
+func StopTape() {
+	atomic.StoreInt32(&tape.mode, tapeOff)
+	setIntercept(interceptTape, false)
+	tape.Lock()
+	tape.enc = nil
+	tape.calls = nil
+	tape.replay = nil
+	tape.Unlock()
+}
+
+// taped records or replays err for the Check whose caller is depth frames above
+// the caller of taped.
+func taped(err error, depth int) error {
+	mode := atomic.LoadInt32(&tape.mode)
+	if mode == tapeOff {
+		return err
+	}
+	site := callSite(depth + 1)
+	tape.Lock()
+	defer tape.Unlock()
+	if tape.calls == nil {
+		return err
+	}
+	tape.calls[site]++
+	call := tape.calls[site]
+	switch mode {
+	case tapeRecording:
+		if err != nil && tape.enc != nil {
+			e := TapeEvent{Site: site, Call: call, Message: err.Error(), Code: Code(err)}
+			if werr := tape.enc.Encode(e); werr != nil {
+				emit(Entry{Severity: SeverityError, Message: "sherlock: could not record error: " + werr.Error()})
+			}
+		}
+	case tapeReplaying:
+		if e, ok := tape.replay[site][call]; ok {
+			return e.err()
+		}
+	}
+	return err
+}
+
+func (e TapeEvent) err() error {
+	if e.Code != "" {
+		if err, ok := byCode(e.Code); ok {
+			return err
+		}
+	}
+	return errors.New(e.Message)
+}
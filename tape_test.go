@@ -0,0 +1,84 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func tapeCheck(err error) (caught error) {
+	defer sherlock.CatchAll(&caught)
+	sherlock.Check(err)
+	return nil
+}
+
+func tapeCheckIn(err error) (caught error) {
+	defer sherlock.CatchAll(&caught)
+	sherlock.CheckIn(context.Background(), err)
+	return nil
+}
+
+func TestTapeSites(t *testing.T) {
+	var buf bytes.Buffer
+	sherlock.Record(&buf)
+	tapeCheck(errors.New("from Check"))
+	tapeCheckIn(errors.New("from CheckIn"))
+	sherlock.StopTape()
+
+	var sites []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e sherlock.TapeEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		sites = append(sites, e.Site)
+	}
+	want := []string{"sherlock_test.tapeCheck", "sherlock_test.tapeCheckIn"}
+	if len(sites) != len(want) {
+		t.Fatalf("recorded sites %q", sites)
+	}
+	for i, site := range sites {
+		if !strings.HasSuffix(site, want[i]) {
+			t.Fatalf("recorded site %q, want %q", site, want[i])
+		}
+	}
+}
+
+func TestTapeReplay(t *testing.T) {
+	errCoded := errors.New("tape test: coded")
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.RegisterCodeMapping(errCoded, "tape_coded")
+
+	var buf bytes.Buffer
+	sherlock.Record(&buf)
+	tapeCheck(nil)
+	tapeCheck(errCoded)
+	tapeCheck(errors.New("tape test: uncoded"))
+	sherlock.StopTape()
+
+	if err := sherlock.Replay(&buf); err != nil {
+		t.Fatal(err)
+	}
+	defer sherlock.StopTape()
+	if err := tapeCheck(nil); err != nil {
+		t.Errorf("first call threw %v, but none was recorded", err)
+	}
+	if err := tapeCheck(nil); !errors.Is(err, errCoded) {
+		t.Errorf("second call threw %v, want the error registered with the recorded code", err)
+	}
+	if err := tapeCheck(nil); err == nil || err.Error() != "tape test: uncoded" {
+		t.Errorf("third call threw %v, want the recorded message", err)
+	}
+	if err := tapeCheck(nil); err != nil {
+		t.Errorf("call past the tape threw %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package sherlock
+
+import (
+	"sync"
+	"time"
+)
+
+// throttleWidth is the number of keys a Throttle tracks before it forgets
+// those that have fully recovered.
+const throttleWidth = 1000
+
+// Throttle rate limits side effects triggered by errors, such as alerts,
+// restarts or cache flushes taken in response to events, separately for each
+// key, which is normally an error's fingerprint. Each key may act Burst times
+// in a row, and then once per Every. A zero Burst means 1. The zero value
+// never limits anything.
+//
+//	throttle := &sherlock.Throttle{Every: time.Minute}
+//	for e := range sherlock.Subscribe(nil) {
+//		throttle.Do(sherlock.Fingerprint(e.Err, ""), func() { alert(e) })
+//	}
+type Throttle struct {
+	Every time.Duration
+	Burst int
+
+	mu   sync.Mutex
+	keys map[string]*throttleKey
+}
+
+type throttleKey struct {
+	tokens float64
+	last   time.Time
+}
+
+// Allow reports whether key may act now, and if so counts it as having acted.
+func (t *Throttle) Allow(key string) bool {
+	if t.Every <= 0 {
+		return true
+	}
+	burst := float64(t.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.keys == nil {
+		t.keys = make(map[string]*throttleKey)
+	}
+	k := t.keys[key]
+	if k == nil {
+		if len(t.keys) >= throttleWidth {
+			t.prune(now, burst)
+		}
+		k = &throttleKey{tokens: burst, last: now}
+		t.keys[key] = k
+	}
+	k.tokens += float64(now.Sub(k.last)) / float64(t.Every)
+	if k.tokens > burst {
+		k.tokens = burst
+	}
+	k.last = now
+	if k.tokens < 1 {
+		return false
+	}
+	k.tokens--
+	return true
+}
+
+// Do calls fn if key may act now, and reports whether it did.
+func (t *Throttle) Do(key string, fn func()) bool {
+	if !t.Allow(key) {
+		return false
+	}
+	fn()
+	return true
+}
+
+// prune forgets the keys that would have recovered their full burst by now.
+func (t *Throttle) prune(now time.Time, burst float64) {
+	for key, k := range t.keys {
+		if k.tokens+float64(now.Sub(k.last))/float64(t.Every) >= burst {
+			delete(t.keys, key)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package sherlock
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is returned by RunWithTimeout when the function it runs does not
+// finish in time. It is registered with the code "timeout" and HTTP status 504
+// Gateway Timeout.
+var ErrTimeout = errors.New("timed out")
+
+func init() {
+	RegisterCodeMapping(ErrTimeout, "timeout")
+	RegisterHTTPStatus(ErrTimeout, 504)
+}
+
+// RunWithTimeout runs fn and returns its error, thrown or returned, unless it
+// takes longer than d, in which case an error matching ErrTimeout is returned
+// instead. fn cannot be stopped, and continues running in the background after
+// a timeout, so it should also observe a deadline of its own where it can.
+// Panics in fn that were not raised by sherlock are reported as bugs and
+// returned as a *PanicError.
+func RunWithTimeout(d time.Duration, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := guard(fn)
+		done <- err
+	}()
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-t.C:
+		return classify(fmt.Errorf("%w after %v", ErrTimeout, d))
+	}
+}
@@ -0,0 +1,47 @@
+//go:build tinygo || sherlock_tiny
+
+package sherlock
+
+import "runtime"
+
+// The reduced build, selected by TinyGo or the sherlock_tiny build tag, is for
+// embedded targets where binary size and allocation matter. It compiles
+// without regexp, net, net/http, encoding/json and runtime/debug, and never
+// calls runtime.Stack. Left out with them are:
+//
+//   - Recoverer and the rest of the HTTP middleware, RenderHTML,
+//     DefaultErrorPage and DebugHandler
+//   - Notifier, Watchdog and Aggregator
+//   - Encode, Decode, WriteCatalog and LoadCatalog
+//   - the stats file, tapes, crash reports and crash loop detection
+//   - SyslogBackend and JournaldBackend
+//
+// Stacks are captured from at most tinyStackFrames program counters, so
+// diagnostics stay a fixed size.
+
+// tinyStackFrames is the number of frames the reduced build captures.
+const tinyStackFrames = 32
+
+func stacktrace() string {
+	var pcs [tinyStackFrames]uintptr
+	n := runtime.Callers(1, pcs[:])
+	return formatStack(pcs[:n])
+}
+
+type reportingState struct{}
+
+func snapshotReporting() reportingState { return reportingState{} }
+
+func restoreReporting(reportingState) {}
+
+func saveStats(force bool) {}
+
+func taped(err error, depth int) error { return err }
+
+func writeCrashReport(r interface{}, stack string) {}
+
+func notify(r interface{}, stack string) {}
+
+func watch() {}
+
+func coolDown(fingerprint string) {}
@@ -0,0 +1,37 @@
+package sherlock_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTinyBuild(t *testing.T) {
+	out, err := exec.Command("go", "list", "-tags", "sherlock_tiny", "-deps", "github.com/alankm/sherlock").Output()
+	if err != nil {
+		t.Skip("go list unavailable:", err)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		switch dep {
+		case "regexp", "net", "net/http", "encoding/json", "runtime/debug":
+			t.Errorf("the reduced build depends on %v", dep)
+		}
+	}
+	if out, err := exec.Command("go", "build", "-tags", "sherlock_tiny", "github.com/alankm/sherlock").CombinedOutput(); err != nil {
+		t.Fatalf("the reduced build does not compile: %v\n%s", err, out)
+	}
+	out, err = exec.Command("go", "list", "-tags", "sherlock_tiny", "-f", `{{join .GoFiles "\n"}}`, "github.com/alankm/sherlock").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range strings.Fields(string(out)) {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(src), "runtime.Stack(") {
+			t.Errorf("%v calls runtime.Stack in the reduced build", name)
+		}
+	}
+}
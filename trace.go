@@ -0,0 +1,30 @@
+package sherlock
+
+import (
+	"context"
+	"runtime/trace"
+)
+
+// traceCategory is the category of the events sherlock logs to an execution
+// trace, and the prefix of the regions it opens.
+const traceCategory = "sherlock"
+
+// traceThrow logs err to the execution trace, if one is being recorded, as it
+// leaves a failing Check, Assert or Throw, so that go tool trace shows where
+// error handling starts on each goroutine.
+func traceThrow(err error) {
+	if !trace.IsEnabled() {
+		return
+	}
+	msg := err.Error()
+	if code := Code(err); code != "" {
+		msg = code + ": " + msg
+	}
+	trace.Log(context.Background(), traceCategory, msg)
+}
+
+// traceRegion opens a region of the execution trace named after a sherlock
+// boundary. It costs next to nothing when no trace is being recorded.
+func traceRegion(ctx context.Context, name string) *trace.Region {
+	return trace.StartRegion(ctx, traceCategory+"."+name)
+}
@@ -0,0 +1,91 @@
+package sherlock
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// defaultUnwrapDepth is the unwrap depth used unless SetUnwrapDepth is called.
+const defaultUnwrapDepth = 100
+
+// unwrapDepth is the most errors that are visited when walking an error chain.
+var unwrapDepth int32 = defaultUnwrapDepth
+
+// SetUnwrapDepth limits how many errors sherlock visits when walking the chain
+// of a wrapped error, which protects against pathological and cyclic Unwrap
+// implementations. The default is 100, and a value of zero or less restores it
+// rather than leaving every error unmatched. A diagnostic is written whenever
+// the limit is reached.
+func SetUnwrapDepth(n int) {
+	if n <= 0 {
+		n = defaultUnwrapDepth
+	}
+	atomic.StoreInt32(&unwrapDepth, int32(n))
+}
+
+// walk calls fn for err and every error it wraps, depth first, until fn
+// returns true. It reports whether fn returned true.
+func walk(err error, fn func(error) bool) bool {
+	limit := int(atomic.LoadInt32(&unwrapDepth))
+	pending := []error{err}
+	for visited := 0; len(pending) > 0; {
+		e := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if e == nil {
+			continue
+		}
+		if visited == limit {
+			emit(Entry{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("sherlock: stopped unwrapping %T after %v errors", err, limit),
+			})
+			return false
+		}
+		visited++
+		if fn(e) {
+			return true
+		}
+		switch x := e.(type) {
+		case interface{ Unwrap() error }:
+			pending = append(pending, x.Unwrap())
+		case interface{ Unwrap() []error }:
+			errs := x.Unwrap()
+			for i := len(errs) - 1; i >= 0; i-- {
+				pending = append(pending, errs[i])
+			}
+		}
+	}
+	return false
+}
+
+// is behaves like errors.Is, but is bounded by the unwrap depth, and matches
+// errors of non-comparable types by type and message rather than never.
+func is(err, target error) bool {
+	if err == nil || target == nil {
+		return err == target
+	}
+	comparable := reflect.TypeOf(target).Comparable()
+	return walk(err, func(e error) bool {
+		if comparable && e == target || !comparable && sameError(e, target) {
+			return true
+		}
+		x, ok := e.(interface{ Is(error) bool })
+		return ok && x.Is(target)
+	})
+}
+
+// coded returns the first *Coded in err's chain, or nil if there is none. As
+// with errors.As, an error in the chain with an As method is asked to supply
+// one.
+func coded(err error) *Coded {
+	var c *Coded
+	walk(err, func(e error) bool {
+		if c, _ = e.(*Coded); c != nil {
+			return true
+		}
+		x, ok := e.(interface{ As(interface{}) bool })
+		return ok && x.As(&c) && c != nil
+	})
+	return c
+}
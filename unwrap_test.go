@@ -0,0 +1,41 @@
+package sherlock_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+// legacy is an error type that exposes a *Coded through an As method rather
+// than by wrapping one.
+type legacy struct{ code string }
+
+func (l legacy) Error() string { return "legacy" }
+
+func (l legacy) As(target interface{}) bool {
+	c, ok := target.(**sherlock.Coded)
+	if ok {
+		*c = &sherlock.Coded{Code: l.code, Message: "legacy"}
+	}
+	return ok
+}
+
+func TestCodeHonoursAs(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", legacy{code: "legacy_code"})
+	if code := sherlock.Code(err); code != "legacy_code" {
+		t.Fatalf("got code %q", code)
+	}
+}
+
+func TestUnwrapDepthNonPositive(t *testing.T) {
+	defer sherlock.SetUnwrapDepth(0)
+	err := fmt.Errorf("wrapped: %w", errors.New("target"))
+	for _, n := range []int{0, -1} {
+		sherlock.SetUnwrapDepth(n)
+		if len(sherlock.Chain(err)) != 2 {
+			t.Fatalf("SetUnwrapDepth(%d) stopped the walk", n)
+		}
+	}
+}
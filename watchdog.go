@@ -0,0 +1,93 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Watchdog escalates once Count unexpected panics have been seen within
+// Window, since a flood of them usually means the process is in an unknown
+// state. On escalation OnTrip is called if set, the stacks of every goroutine
+// are written as a fatal diagnostic if Dump is set, and the process exits with
+// code 2 if Exit is set. A zero Window counts panics since the watchdog was
+// installed. Once it has escalated the watchdog starts counting afresh.
+type Watchdog struct {
+	Count  int
+	Window time.Duration
+	OnTrip func()
+	Dump   bool
+	Exit   bool
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+var watchdog struct {
+	sync.Mutex
+	w *Watchdog
+}
+
+// SetWatchdog installs w to watch for unexpected panics. A nil w removes the
+// watchdog, which is the default.
+func SetWatchdog(w *Watchdog) {
+	watchdog.Lock()
+	watchdog.w = w
+	watchdog.Unlock()
+}
+
+func watch() {
+	watchdog.Lock()
+	w := watchdog.w
+	watchdog.Unlock()
+	if w != nil && w.observe(time.Now()) {
+		w.trip()
+	}
+}
+
+func (w *Watchdog) observe(now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.Window > 0 {
+		i := 0
+		for i < len(w.times) && now.Sub(w.times[i]) > w.Window {
+			i++
+		}
+		w.times = w.times[i:]
+	}
+	w.times = append(w.times, now)
+	if len(w.times) < w.Count {
+		return false
+	}
+	w.times = nil
+	return true
+}
+
+func (w *Watchdog) trip() {
+	if w.OnTrip != nil {
+		w.OnTrip()
+	}
+	if w.Dump {
+		buf := make([]byte, 1<<20)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				buf = buf[:n]
+				break
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+		emit(Entry{
+			Severity: SeverityFatal,
+			Message:  "sherlock: watchdog tripped, dumping all goroutines",
+			Stack:    string(buf),
+		})
+	}
+	if w.Exit {
+		Close()
+		os.Exit(2)
+	}
+}
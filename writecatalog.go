@@ -0,0 +1,175 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// MarshalJSON encodes the entry with its severity by name.
+func (e CatalogEntry) MarshalJSON() ([]byte, error) {
+	type entry CatalogEntry
+	return json.Marshal(struct {
+		entry
+		Severity string `json:"severity"`
+	}{entry(e), e.Severity.String()})
+}
+
+// UnmarshalJSON decodes an entry written by MarshalJSON. An entry without a
+// severity decodes with SeverityInfo.
+func (e *CatalogEntry) UnmarshalJSON(b []byte) error {
+	type entry CatalogEntry
+	v := struct {
+		*entry
+		Severity string `json:"severity"`
+	}{entry: (*entry)(e)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	e.Severity = SeverityInfo
+	if v.Severity != "" {
+		sev, ok := parseSeverity(v.Severity)
+		if !ok {
+			return fmt.Errorf("sherlock: unknown severity %q", v.Severity)
+		}
+		e.Severity = sev
+	}
+	return nil
+}
+
+// catalogFile is the JSON written for catalogs with a version.
+type catalogFile struct {
+	Version int            `json:"version"`
+	Errors  []CatalogEntry `json:"errors"`
+}
+
+// CatalogFormat is the format WriteCatalog writes in.
+type CatalogFormat string
+
+const (
+	CatalogMarkdown CatalogFormat = "markdown"
+	CatalogJSON     CatalogFormat = "json"
+)
+
+// WriteCatalog writes the catalog of registered errors to w in the given
+// format, so that a reference of the errors a service can return can be
+// generated from the code itself. JSON catalogs can be loaded back with
+// LoadCatalog, and are tagged with the version set by SetCatalogVersion.
+func WriteCatalog(w io.Writer, format CatalogFormat) error {
+	entries := Catalog()
+	switch format {
+	case CatalogJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		if v := atomic.LoadInt32(&catalogVersion); v != 0 {
+			return enc.Encode(catalogFile{int(v), entries})
+		}
+		return enc.Encode(entries)
+	case CatalogMarkdown:
+		var b strings.Builder
+		b.WriteString("| Error | Code | Status | Public message | Severity | Retryable | Hint |\n")
+		b.WriteString("|---|---|---|---|---|---|---|\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "| %v | %v | %v | %v | %v | %v | %v |\n",
+				markdownCell(e.Error), markdownCell(e.Code), e.HTTPStatus, markdownCell(e.Public),
+				e.Severity, e.Retryable, markdownCell(e.Hint))
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("sherlock: unknown catalog format %q", format)
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// CatalogMigration rewrites the entries of a catalog written with an older
+// version, so that LoadCatalog can load them with the current one. It may
+// rename codes, change severities, or drop or add entries as the rules have
+// changed since version from.
+type CatalogMigration func(from int, entries []CatalogEntry) ([]CatalogEntry, error)
+
+// LoadCatalog registers the entries of a JSON catalog written by WriteCatalog,
+// so that rule sets can be persisted and shared between services. Each entry
+// applies to the error already registered with its code, or else to the
+// registered error with its message, or else to a new error with its message,
+// which matches errors raised elsewhere by message once SetMatchByMessage is
+// enabled. The errors are returned in the order of the entries.
+//
+// A catalog written with an older version than the one set by
+// SetCatalogVersion is passed to migrate first, and is an error if migrate is
+// nil. A catalog written with a newer version is always an error, since its
+// rules may mean something this program does not know.
+func LoadCatalog(r io.Reader, migrate CatalogMigration) ([]error, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var file catalogFile
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "[") {
+		err = json.Unmarshal(b, &file.Errors)
+	} else {
+		err = json.Unmarshal(b, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sherlock: malformed catalog: %w", err)
+	}
+	version := int(atomic.LoadInt32(&catalogVersion))
+	switch {
+	case file.Version > version:
+		return nil, fmt.Errorf("sherlock: catalog version %v is newer than %v", file.Version, version)
+	case file.Version < version && migrate == nil:
+		return nil, fmt.Errorf("sherlock: catalog version %v needs migrating to %v", file.Version, version)
+	case file.Version < version:
+		if file.Errors, err = migrate(file.Version, file.Errors); err != nil {
+			return nil, fmt.Errorf("sherlock: migrating catalog from version %v: %w", file.Version, err)
+		}
+	}
+	errs := make([]error, 0, len(file.Errors))
+	for _, e := range file.Errors {
+		err := catalogTarget(e)
+		if e.Code != "" {
+			RegisterCodeMapping(err, e.Code)
+		}
+		if e.HTTPStatus != 0 {
+			RegisterHTTPStatus(err, e.HTTPStatus)
+		}
+		if e.Public != "" {
+			RegisterPublicMessage(err, e.Public)
+		}
+		if e.Hint != "" {
+			RegisterHint(err, e.Hint)
+		}
+		if e.MessageKey != "" {
+			RegisterMessageKey(err, e.MessageKey)
+		}
+		if e.Retryable {
+			RegisterRetryable(err)
+		}
+		RegisterSeverity(err, e.Severity)
+		errs = append(errs, err)
+	}
+	return errs, nil
+}
+
+// catalogTarget returns the error that the catalog entry e applies to.
+func catalogTarget(e CatalogEntry) error {
+	if e.Code != "" {
+		if err, ok := byCode(e.Code); ok {
+			return err
+		}
+	}
+	for _, err := range registeredErrors() {
+		if err.Error() == e.Error {
+			return err
+		}
+	}
+	return errors.New(e.Error)
+}
@@ -0,0 +1,91 @@
+//go:build !tinygo && !sherlock_tiny
+
+package sherlock_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alankm/sherlock"
+)
+
+func TestLoadCatalog(t *testing.T) {
+	errStale := errors.New("stale")
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.SetCatalogVersion(1)
+	sherlock.RegisterCodeMapping(errStale, "stale")
+	sherlock.RegisterHTTPStatus(errStale, 409)
+	sherlock.RegisterSeverity(errStale, sherlock.SeverityWarning)
+	var b bytes.Buffer
+	if err := sherlock.WriteCatalog(&b, sherlock.CatalogJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"version": 1`) {
+		t.Fatalf("catalog has no version:\n%v", b.String())
+	}
+	sherlock.Restore(s)
+
+	sherlock.SetCatalogVersion(2)
+	if _, err := sherlock.LoadCatalog(bytes.NewReader(b.Bytes()), nil); err == nil {
+		t.Error("older catalog loaded without a migration")
+	}
+	var from int
+	errs, err := sherlock.LoadCatalog(bytes.NewReader(b.Bytes()), func(v int, entries []sherlock.CatalogEntry) ([]sherlock.CatalogEntry, error) {
+		from = v
+		for i := range entries {
+			if entries[i].Code == "stale" {
+				entries[i].Code = "conflict"
+			}
+		}
+		return entries, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1 {
+		t.Errorf("migrated from version %v, want 1", from)
+	}
+	var loaded error
+	for _, err := range errs {
+		if err.Error() == "stale" {
+			loaded = err
+		}
+	}
+	if loaded == nil {
+		t.Fatalf("no error loaded for %q: %v", errStale, errs)
+	}
+	if code := sherlock.Code(loaded); code != "conflict" {
+		t.Errorf("code %q, want conflict", code)
+	}
+	if s := sherlock.Structured(loaded); s.HTTPStatus != 409 {
+		t.Errorf("status %v, want 409", s.HTTPStatus)
+	}
+	if sev := sherlock.SeverityOf(loaded); sev != sherlock.SeverityWarning {
+		t.Errorf("severity %v, want warning", sev)
+	}
+
+	sherlock.SetCatalogVersion(0)
+	if _, err := sherlock.LoadCatalog(bytes.NewReader(b.Bytes()), nil); err == nil {
+		t.Error("newer catalog loaded")
+	}
+}
+
+func TestLoadCatalogTarget(t *testing.T) {
+	errTarget := errors.New("target")
+	s := sherlock.Snapshot()
+	defer sherlock.Restore(s)
+	sherlock.RegisterCodeMapping(errTarget, "target")
+	errs, err := sherlock.LoadCatalog(strings.NewReader(`[{"error": "renamed", "code": "target", "public": "Try again"}]`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0] != errTarget {
+		t.Fatalf("loaded %v, want the error registered with the code", errs)
+	}
+	if s := sherlock.Structured(errTarget); s.Public != "Try again" {
+		t.Errorf("public message %q", s.Public)
+	}
+}